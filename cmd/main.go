@@ -1,33 +1,119 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/api"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
 	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/db"
-	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
 
+const usage = `Usage: %s [-config file] <command> [arguments]
+
+Commands:
+  serve [-skip-migrations]   run the HTTP server (default)
+  migrate up [N]             apply all or N pending migrations
+  migrate down [-all] [N]    roll back N migrations (default 1) or all of them
+  migrate steps N            apply (N > 0) or roll back (N < 0) N migrations
+  migrate goto V             migrate up or down to version V
+  migrate force V            set version V without running migrations (clears the dirty flag)
+  migrate version            print the current version and dirty flag
+  hash-api-key KEY           print the hash to put into AUTH_API_KEYS or auth.api_keys
+`
+
+// @title Person Enrichment Service API
+// @version 1.0
+// @description API для управления данными о людях с обогащением информации
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT bearer token, e.g. "Bearer eyJhbGciOi..."
 func main() {
 	logrus.SetFormatter(&logrus.JSONFormatter{})
 
-	err := godotenv.Load()
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	configPath := flag.String("config", "", "path to a YAML or TOML config file (overrides CONFIG_FILE)")
+	flag.Parse()
+
+	args := flag.Args()
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	// hash-api-key needs no configuration, which may not be valid yet
+	// precisely because the key is still missing.
+	if command == "hash-api-key" {
+		if err := hashAPIKey(args); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		logrus.Warn("Error loading .env file")
+		logrus.Fatal("Failed to load configuration: ", err)
 	}
 
-	database, err := db.InitDB()
+	switch command {
+	case "serve":
+		err = serve(cfg, args)
+	case "migrate":
+		err = runMigrate(cfg, args)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 	if err != nil {
-		logrus.Fatal("Failed to connect to database: ", err)
+		logrus.Fatal(err)
 	}
-	defer database.Close()
+}
+
+func serve(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	skipMigrations := fs.Bool("skip-migrations", !cfg.Database.AutoMigrate, "do not apply pending migrations on startup")
+	fs.Parse(args)
 
-	if err := db.RunMigrations(database); err != nil {
-		logrus.Fatal("Failed to run migrations: ", err)
+	database, err := db.InitDB(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer func() {
+		if err := database.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close database")
+			return
+		}
+		logrus.Info("Database connection closed")
+	}()
+
+	if *skipMigrations {
+		logrus.Info("Skipping database migrations")
+	} else if err := db.RunMigrations(database, cfg.Database.MigrationsSource); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	logrus.WithField("addr", cfg.Server.Addr).Info("Starting server")
+	if err := api.StartServer(database, cfg); err != nil {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
 
-	serverAddr := db.GetEnv("SERVER_ADDR", ":8080")
-	logrus.WithField("addr", serverAddr).Info("Starting server")
-	if err := api.StartServer(database, serverAddr); err != nil {
-		logrus.Fatal("Server failed: ", err)
+func hashAPIKey(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("hash-api-key: expected exactly one key")
 	}
+	fmt.Println(auth.HashAPIKey(args[0]))
+	return nil
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/db"
+	migrate "github.com/golang-migrate/migrate/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: missing subcommand (up, down, steps, goto, force, version)")
+	}
+	sub, args := args[0], args[1:]
+
+	database, err := db.InitDB(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer database.Close()
+
+	m, err := db.NewMigrator(database, cfg.Database.MigrationsSource)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch sub {
+	case "up":
+		if len(args) == 0 {
+			err = m.Up()
+			break
+		}
+		var n int
+		if n, err = positiveArg(sub, args); err == nil {
+			err = m.Steps(n)
+		}
+	case "down":
+		// Only down takes flags: steps and force accept negative numbers.
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		all := fs.Bool("all", false, "roll back every migration")
+		fs.Parse(args)
+		args = fs.Args()
+		if *all {
+			err = m.Down()
+			break
+		}
+		n := 1
+		if len(args) > 0 {
+			if n, err = positiveArg(sub, args); err != nil {
+				break
+			}
+		}
+		err = m.Steps(-n)
+	case "steps":
+		var n int
+		if n, err = intArg(sub, args); err == nil {
+			err = m.Steps(n)
+		}
+	case "goto":
+		var v int
+		if v, err = positiveArg(sub, args); err == nil {
+			err = m.Migrate(uint(v))
+		}
+	case "force":
+		var v int
+		if v, err = intArg(sub, args); err == nil {
+			err = m.Force(v)
+		}
+	case "version":
+		return printVersion(m)
+	default:
+		return fmt.Errorf("migrate: unknown subcommand %q", sub)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) {
+		logrus.Info("No migrations to apply")
+		return printVersion(m)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s: %w", sub, err)
+	}
+	return printVersion(m)
+}
+
+func printVersion(m *migrate.Migrate) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("no migrations applied")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read migration version: %w", err)
+	}
+	fmt.Printf("version %d, dirty %t\n", version, dirty)
+	return nil
+}
+
+func intArg(sub string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("migrate %s: expected exactly one numeric argument", sub)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("migrate %s: invalid number %q", sub, args[0])
+	}
+	return n, nil
+}
+
+func positiveArg(sub string, args []string) (int, error) {
+	n, err := intArg(sub, args)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("migrate %s: argument must be positive, got %d", sub, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetEnrichmentQuotas godoc
+// @Summary Get enrichment provider quotas
+// @Description Returns the last known request quota of each enrichment API and whether calls to it are currently throttled
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.ProviderQuota
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/quotas [get]
+func (h *Handler) GetEnrichmentQuotas(c *gin.Context) {
+	c.JSON(http.StatusOK, h.enrich.Quotas())
+}
+
+// GetOfflineDataset godoc
+// @Summary Get the offline enrichment dataset
+// @Description Returns the file, number of names and load time of the offline dataset
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.OfflineDataset
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Offline provider disabled"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/offline [get]
+func (h *Handler) GetOfflineDataset(c *gin.Context) {
+	status, ok := h.enrich.OfflineDataset()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offline provider disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ReloadOfflineDataset godoc
+// @Summary Reload the offline enrichment dataset
+// @Description Re-reads the offline dataset file without a restart. On error the previously loaded data stays in use. Sending SIGHUP to the process does the same.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} models.OfflineDataset
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Offline provider disabled"
+// @Failure 422 {object} models.ErrorResponse "Dataset could not be loaded"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/offline/reload [post]
+func (h *Handler) ReloadOfflineDataset(c *gin.Context) {
+	if _, ok := h.enrich.OfflineDataset(); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offline provider disabled"})
+		return
+	}
+	if err := h.enrich.ReloadOffline(); err != nil {
+		logrus.WithError(err).Error("Failed to reload offline dataset")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, _ := h.enrich.OfflineDataset()
+	logrus.WithFields(logrus.Fields{
+		"entries":   status.Entries,
+		"principal": principalName(c),
+	}).Info("Offline dataset reloaded")
+	c.JSON(http.StatusOK, status)
+}
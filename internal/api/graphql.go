@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// Limits on a single GraphQL request, which the rate limiter charges as one
+// read. The depth leaves room for the introspection query of GraphiQL and
+// similar clients; the schema itself is only four levels deep.
+const (
+	graphqlMaxDepth       = 15
+	graphqlMaxParallelism = 4
+	graphqlMaxQueryLength = 16 << 10
+)
+
+// newGraphQLSchema serves the schema in schema.graphql from persons.
+func newGraphQLSchema(persons *repository.Persons) *graphql.Schema {
+	return graphql.MustParseSchema(graphqlSchema, &graphqlResolver{persons: persons},
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxParallelism(graphqlMaxParallelism),
+		graphql.MaxQueryLength(graphqlMaxQueryLength),
+	)
+}
+
+// GraphQL godoc
+// @Summary Query and change persons with GraphQL
+// @Description Executes a GraphQL query or mutation against the schema in internal/api/schema.graphql, which the endpoint also answers introspection queries for. Fields are resolved by the same code as the REST endpoints, and each field checks the permission of its REST counterpart. Errors are returned in the errors array with extensions.code set to BAD_USER_INPUT, NOT_FOUND, FORBIDDEN, RATE_LIMITED or INTERNAL. createPerson counts against the enrich rate limit and the other mutations against the write limit.
+// @Tags persons
+// @Accept json
+// @Produce json
+// @Param request body models.GraphQLRequest true "GraphQL request"
+// @Success 200 {object} models.GraphQLResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /graphql [post]
+func (h *Handler) GraphQL(c *gin.Context) {
+	var req models.GraphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), chargeKey{}, func(class string) bool {
+		return h.limits.charge(c, class)
+	})
+	resp := h.graphql.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	logrus.WithFields(logrus.Fields{
+		"operation": req.OperationName,
+		"errors":    len(resp.Errors),
+	}).Debug("Executed GraphQL request")
+	c.JSON(http.StatusOK, resp)
+}
+
+// chargeKey stores a func(class string) bool in the request context that
+// takes a request of class from the caller's rate limit bucket.
+type chargeKey struct{}
+
+// graphqlError carries a GraphQL error code in extensions.code.
+type graphqlError struct {
+	code, msg string
+}
+
+func (e *graphqlError) Error() string {
+	return e.msg
+}
+
+func (e *graphqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func badInput(msg string) error {
+	return &graphqlError{code: "BAD_USER_INPUT", msg: msg}
+}
+
+// graphqlAllowed checks perm and, for mutations, charges class. Requests
+// without a principal only get here when authentication is disabled.
+func graphqlAllowed(ctx context.Context, perm auth.Permission, class string) error {
+	if p := auth.FromContext(ctx); p != nil && !p.Can(perm) {
+		logrus.WithFields(logrus.Fields{
+			"principal":  p.Subject,
+			"roles":      p.Roles,
+			"permission": perm,
+			"path":       "/graphql",
+		}).Warn("Permission denied")
+		return &graphqlError{code: "FORBIDDEN", msg: "principal " + p.Subject + " lacks permission " + string(perm)}
+	}
+	if charge, ok := ctx.Value(chargeKey{}).(func(string) bool); ok && class != "" && !charge(class) {
+		return &graphqlError{code: "RATE_LIMITED", msg: "Rate limit exceeded"}
+	}
+	return nil
+}
+
+// graphqlFailed maps a repository error, logging unexpected ones. failure
+// is the message for those.
+func graphqlFailed(err error, failure string) error {
+	var invalid *repository.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return badInput(invalid.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		return &graphqlError{code: "NOT_FOUND", msg: "Person not found"}
+	default:
+		logrus.WithError(err).Error(failure)
+		return &graphqlError{code: "INTERNAL", msg: failure}
+	}
+}
+
+func parseGraphQLID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, badInput("Invalid ID")
+	}
+	return n, nil
+}
+
+type graphqlResolver struct {
+	persons *repository.Persons
+}
+
+type personFilterInput struct {
+	Name        *string
+	Surname     *string
+	Patronymic  *string
+	Age         *int32
+	Gender      *string
+	Nationality *string
+}
+
+func (r *graphqlResolver) Person(ctx context.Context, args struct{ ID graphql.ID }) (*personResolver, error) {
+	if err := graphqlAllowed(ctx, auth.PermPersonsRead, ""); err != nil {
+		return nil, err
+	}
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	person, err := r.persons.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, graphqlFailed(err, "Failed to fetch person")
+	}
+	return &personResolver{person}, nil
+}
+
+func (r *graphqlResolver) Persons(ctx context.Context, args struct {
+	Filter *personFilterInput
+	Limit  int32
+	Offset int32
+}) ([]*personResolver, error) {
+	if err := graphqlAllowed(ctx, auth.PermPersonsRead, ""); err != nil {
+		return nil, err
+	}
+	if args.Limit < 0 {
+		return nil, badInput("Invalid limit parameter")
+	}
+	if args.Offset < 0 {
+		return nil, badInput("Invalid offset parameter")
+	}
+
+	var filter repository.Filter
+	if f := args.Filter; f != nil {
+		filter = repository.Filter{
+			Name:        deref(f.Name),
+			Surname:     deref(f.Surname),
+			Patronymic:  deref(f.Patronymic),
+			Age:         intPtr(f.Age),
+			Gender:      deref(f.Gender),
+			Nationality: deref(f.Nationality),
+		}
+	}
+	persons, err := r.persons.List(ctx, filter, int(args.Limit), int(args.Offset))
+	if err != nil {
+		return nil, graphqlFailed(err, "Failed to list persons")
+	}
+	resolvers := make([]*personResolver, len(persons))
+	for i, p := range persons {
+		resolvers[i] = &personResolver{p}
+	}
+	return resolvers, nil
+}
+
+func (r *graphqlResolver) CreatePerson(ctx context.Context, args struct {
+	Input struct {
+		Name       *string
+		Surname    *string
+		Patronymic *string
+		FullName   *string
+		CountryID  *string
+	}
+}) (*personResolver, error) {
+	if err := graphqlAllowed(ctx, auth.PermPersonsWrite, limitEnrich); err != nil {
+		return nil, err
+	}
+	in := args.Input
+	req := models.PersonRequest{
+		Name:       deref(in.Name),
+		Surname:    deref(in.Surname),
+		Patronymic: in.Patronymic,
+		FullName:   deref(in.FullName),
+		CountryID:  in.CountryID,
+	}
+	// The binding tags that gin checks on POST /persons.
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, badInput(fmt.Sprintf("Invalid input: %v", err))
+	}
+
+	person, err := r.persons.Create(ctx, req)
+	if err != nil {
+		return nil, graphqlFailed(err, "Failed to create person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully created")
+	return &personResolver{person}, nil
+}
+
+func (r *graphqlResolver) UpdatePerson(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Name        *string
+		Surname     *string
+		Patronymic  *string
+		Age         *int32
+		Gender      *string
+		Nationality *string
+	}
+}) (*personResolver, error) {
+	if err := graphqlAllowed(ctx, auth.PermPersonsWrite, limitWrite); err != nil {
+		return nil, err
+	}
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	in := args.Input
+	person, err := r.persons.Patch(ctx, id, models.PersonPatch{
+		Name:        in.Name,
+		Surname:     in.Surname,
+		Patronymic:  in.Patronymic,
+		Age:         intPtr(in.Age),
+		Gender:      in.Gender,
+		Nationality: in.Nationality,
+	})
+	if err != nil {
+		return nil, graphqlFailed(err, "Failed to update person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully updated")
+	return &personResolver{person}, nil
+}
+
+func (r *graphqlResolver) DeletePerson(ctx context.Context, args struct{ ID graphql.ID }) (*personResolver, error) {
+	if err := graphqlAllowed(ctx, auth.PermPersonsDelete, limitWrite); err != nil {
+		return nil, err
+	}
+	id, err := parseGraphQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	person, err := r.persons.Delete(ctx, id)
+	if err != nil {
+		return nil, graphqlFailed(err, "Failed to delete person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully deleted")
+	return &personResolver{person}, nil
+}
+
+type personResolver struct {
+	p models.Person
+}
+
+func (r *personResolver) ID() graphql.ID           { return graphql.ID(strconv.Itoa(r.p.ID)) }
+func (r *personResolver) Name() string             { return r.p.Name }
+func (r *personResolver) Surname() string          { return r.p.Surname }
+func (r *personResolver) Patronymic() *string      { return r.p.Patronymic }
+func (r *personResolver) Age() *int32              { return int32Ptr(r.p.Age) }
+func (r *personResolver) Gender() *string          { return r.p.Gender }
+func (r *personResolver) Nationality() *string     { return r.p.Nationality }
+func (r *personResolver) NameLatin() *string       { return r.p.NameLatin }
+func (r *personResolver) SurnameLatin() *string    { return r.p.SurnameLatin }
+func (r *personResolver) PatronymicLatin() *string { return r.p.PatronymicLatin }
+
+func (r *personResolver) Enrichment() *enrichmentResolver {
+	if r.p.Enrichment == nil {
+		return nil
+	}
+	return &enrichmentResolver{r.p.Enrichment}
+}
+
+type enrichmentResolver struct {
+	e *models.Enrichment
+}
+
+func (r *enrichmentResolver) Age() *attributeResolver         { return attribute(r.e.Age) }
+func (r *enrichmentResolver) Gender() *attributeResolver      { return attribute(r.e.Gender) }
+func (r *enrichmentResolver) Nationality() *attributeResolver { return attribute(r.e.Nationality) }
+
+func attribute(a *models.AttributeEnrichment) *attributeResolver {
+	if a == nil {
+		return nil
+	}
+	return &attributeResolver{a}
+}
+
+type attributeResolver struct {
+	a *models.AttributeEnrichment
+}
+
+func (r *attributeResolver) Status() string        { return r.a.Status }
+func (r *attributeResolver) Provider() string      { return r.a.Provider }
+func (r *attributeResolver) Rule() *string         { return optional(r.a.Rule) }
+func (r *attributeResolver) Probability() *float64 { return r.a.Probability }
+func (r *attributeResolver) Count() *int32         { return int32Ptr(r.a.Count) }
+func (r *attributeResolver) Reason() *string       { return optional(r.a.Reason) }
+
+func (r *attributeResolver) Value() *string {
+	if r.a.Value == nil {
+		return nil
+	}
+	v := fmt.Sprint(r.a.Value)
+	return &v
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	i := int32(*v)
+	return &i
+}
@@ -0,0 +1,506 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/api/personsv1"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/outbox"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/repository"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcPermissions is the permission each PersonService method requires,
+// matching the REST route it mirrors. Enrich has no REST route and needs
+// persons:write like the other calls that change a person.
+var grpcPermissions = map[string]auth.Permission{
+	personsv1.PersonService_Get_FullMethodName:    auth.PermPersonsRead,
+	personsv1.PersonService_List_FullMethodName:   auth.PermPersonsRead,
+	personsv1.PersonService_Watch_FullMethodName:  auth.PermPersonsRead,
+	personsv1.PersonService_Create_FullMethodName: auth.PermPersonsWrite,
+	personsv1.PersonService_Update_FullMethodName: auth.PermPersonsWrite,
+	personsv1.PersonService_Patch_FullMethodName:  auth.PermPersonsWrite,
+	personsv1.PersonService_Enrich_FullMethodName: auth.PermPersonsWrite,
+	personsv1.PersonService_Delete_FullMethodName: auth.PermPersonsDelete,
+}
+
+// grpcLimits is the rate limit class each PersonService method is charged
+// to, like the REST route it mirrors. Enrich calls the providers as
+// POST /persons does.
+var grpcLimits = map[string]string{
+	personsv1.PersonService_Get_FullMethodName:    limitRead,
+	personsv1.PersonService_List_FullMethodName:   limitRead,
+	personsv1.PersonService_Watch_FullMethodName:  limitRead,
+	personsv1.PersonService_Create_FullMethodName: limitEnrich,
+	personsv1.PersonService_Enrich_FullMethodName: limitEnrich,
+	personsv1.PersonService_Update_FullMethodName: limitWrite,
+	personsv1.PersonService_Patch_FullMethodName:  limitWrite,
+	personsv1.PersonService_Delete_FullMethodName: limitWrite,
+}
+
+// newGRPCServer serves PersonService from persons and feed. With
+// authenticators, callers send the same credentials as to the REST API, as
+// metadata: the API key header or authorization: Bearer <token>. Calls
+// count against the same rate limits as REST requests.
+func newGRPCServer(cfg config.GRPCConfig, persons *repository.Persons, feed *outbox.Feed, authenticators []auth.Authenticator, rbac *auth.RBAC, rl *rateLimiter) *grpc.Server {
+	g := &grpcAuth{authenticators: authenticators, rbac: rbac}
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(g.unary, rl.grpcUnary),
+		grpc.ChainStreamInterceptor(g.stream, rl.grpcStream),
+	)
+	personsv1.RegisterPersonServiceServer(s, &personService{persons: persons, feed: feed})
+	if cfg.Reflection {
+		reflection.Register(s)
+	}
+	return s
+}
+
+// grpcAuth authenticates and authorizes gRPC calls like the authenticate,
+// requirePermission and auditLog middleware do for REST requests. Without
+// authenticators every call is allowed.
+type grpcAuth struct {
+	authenticators []auth.Authenticator
+	rbac           *auth.RBAC
+}
+
+func (g *grpcAuth) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := g.authorize(ctx, info.FullMethod)
+	var resp interface{}
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	audit(ctx, info.FullMethod, err)
+	return resp, err
+}
+
+func (g *grpcAuth) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.authorize(ss.Context(), info.FullMethod)
+	if err == nil {
+		err = handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+	audit(ctx, info.FullMethod, err)
+	return err
+}
+
+// authorize stores the caller in the returned context and checks that it
+// may call method.
+func (g *grpcAuth) authorize(ctx context.Context, method string) (context.Context, error) {
+	if g.authenticators == nil {
+		return ctx, nil
+	}
+
+	// The authenticators read credentials from HTTP headers, which is what
+	// gRPC metadata is on the wire.
+	md, _ := metadata.FromIncomingContext(ctx)
+	r := &http.Request{Header: http.Header{}}
+	for key, values := range md {
+		for _, v := range values {
+			r.Header.Add(key, v)
+		}
+	}
+
+	var principal *auth.Principal
+	for _, a := range g.authenticators {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("method", method).Warn("Authentication failed")
+			return ctx, status.Error(codes.Unauthenticated, "Invalid credentials")
+		}
+		principal = p
+		break
+	}
+	if principal == nil {
+		logrus.WithField("method", method).Warn("Request without credentials")
+		return ctx, status.Error(codes.Unauthenticated, "Authentication required")
+	}
+	principal.Permissions = g.rbac.Permissions(principal.Roles)
+	ctx = auth.WithPrincipal(ctx, principal)
+
+	perm, ok := grpcPermissions[method]
+	if !ok || !principal.Can(perm) {
+		logrus.WithFields(logrus.Fields{
+			"principal":  principal.Subject,
+			"roles":      principal.Roles,
+			"permission": perm,
+			"method":     method,
+		}).Warn("Permission denied")
+		return ctx, status.Errorf(codes.PermissionDenied, "principal %s lacks permission %s", principal.Subject, perm)
+	}
+	return ctx, nil
+}
+
+func (rl *rateLimiter) grpcUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := rl.grpcAllow(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (rl *rateLimiter) grpcStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := rl.grpcAllow(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// grpcAllow takes a call to method from the caller's bucket. Like the REST
+// middleware it lets calls through when rate limiting is disabled or the
+// limiter fails, and it sends the wait as retry-after metadata.
+func (rl *rateLimiter) grpcAllow(ctx context.Context, method string) error {
+	if rl == nil {
+		return nil
+	}
+	class, ok := grpcLimits[method]
+	if !ok {
+		return nil
+	}
+	res, ok := rl.allowClient(ctx, grpcClientKey(ctx), class)
+	if !ok || res.Allowed {
+		return nil
+	}
+	retryAfter := int(math.Max(1, math.Ceil(res.RetryAfter.Seconds())))
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+	return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+}
+
+// grpcClientKey is clientKey for gRPC calls, which carry the peer address
+// instead of a gin context.
+func grpcClientKey(ctx context.Context) string {
+	if p := auth.FromContext(ctx); p != nil {
+		return p.Method + ":" + p.Subject
+	}
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+	}
+	return "ip:" + addr
+}
+
+// audit records who called which method and with what outcome.
+func audit(ctx context.Context, method string, err error) {
+	fields := logrus.Fields{
+		"audit":     true,
+		"method":    method,
+		"code":      status.Code(err).String(),
+		"principal": "anonymous",
+	}
+	if p := auth.FromContext(ctx); p != nil {
+		fields["principal"] = p.Subject
+		fields["auth_method"] = p.Method
+	}
+	logrus.WithFields(fields).Info("Audit")
+}
+
+// authorizedStream carries the context with the principal into streaming
+// handlers.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+// personService implements PersonService on the repository the REST
+// handlers use.
+type personService struct {
+	personsv1.UnimplementedPersonServiceServer
+	persons *repository.Persons
+	// feed is nil when the event stream is disabled.
+	feed *outbox.Feed
+}
+
+func (s *personService) Get(ctx context.Context, req *personsv1.GetRequest) (*personsv1.Person, error) {
+	person, err := s.persons.Get(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(err, "Failed to fetch person")
+	}
+	return personToProto(person), nil
+}
+
+func (s *personService) List(ctx context.Context, req *personsv1.ListRequest) (*personsv1.ListResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = 10
+	}
+	if limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid limit parameter")
+	}
+	if req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid offset parameter")
+	}
+
+	persons, err := s.persons.List(ctx, filterFromProto(req.GetFilter()), limit, int(req.GetOffset()))
+	if err != nil {
+		return nil, grpcError(err, "Failed to list persons")
+	}
+	resp := &personsv1.ListResponse{Persons: make([]*personsv1.Person, len(persons))}
+	for i, p := range persons {
+		resp.Persons[i] = personToProto(p)
+	}
+	return resp, nil
+}
+
+func (s *personService) Create(ctx context.Context, req *personsv1.CreateRequest) (*personsv1.Person, error) {
+	r := models.PersonRequest{
+		Name:       req.GetName(),
+		Surname:    req.GetSurname(),
+		Patronymic: req.Patronymic,
+		FullName:   req.GetFullName(),
+		CountryID:  req.CountryId,
+	}
+	// The binding tags that gin checks on POST /persons.
+	if err := binding.Validator.ValidateStruct(&r); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid request: %v", err)
+	}
+
+	person, err := s.persons.Create(ctx, r)
+	if err != nil {
+		return nil, grpcError(err, "Failed to create person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully created")
+	return personToProto(person), nil
+}
+
+func (s *personService) Update(ctx context.Context, req *personsv1.UpdateRequest) (*personsv1.Person, error) {
+	person := models.Person{
+		Name:        req.GetName(),
+		Surname:     req.GetSurname(),
+		Patronymic:  req.Patronymic,
+		Age:         intPtr(req.Age),
+		Gender:      req.Gender,
+		Nationality: req.Nationality,
+	}
+	person, err := s.persons.Update(ctx, int(req.GetId()), person)
+	if err != nil {
+		return nil, grpcError(err, "Failed to update person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully updated")
+	return personToProto(person), nil
+}
+
+func (s *personService) Patch(ctx context.Context, req *personsv1.PatchRequest) (*personsv1.Person, error) {
+	patch := models.PersonPatch{
+		Name:        req.Name,
+		Surname:     req.Surname,
+		Patronymic:  req.Patronymic,
+		Age:         intPtr(req.Age),
+		Gender:      req.Gender,
+		Nationality: req.Nationality,
+	}
+	person, err := s.persons.Patch(ctx, int(req.GetId()), patch)
+	if err != nil {
+		return nil, grpcError(err, "Failed to update person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully updated")
+	return personToProto(person), nil
+}
+
+func (s *personService) Delete(ctx context.Context, req *personsv1.DeleteRequest) (*personsv1.Person, error) {
+	person, err := s.persons.Delete(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, grpcError(err, "Failed to delete person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person successfully deleted")
+	return personToProto(person), nil
+}
+
+func (s *personService) Enrich(ctx context.Context, req *personsv1.EnrichRequest) (*personsv1.Person, error) {
+	person, err := s.persons.Enrich(ctx, int(req.GetId()), req.GetOnlyMissing())
+	if err != nil {
+		return nil, grpcError(err, "Failed to enrich person")
+	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": contextPrincipal(ctx),
+	}).Info("Person re-enriched")
+	return personToProto(person), nil
+}
+
+// Watch follows the feed like StreamPersons; gRPC keepalives take the
+// place of the heartbeat.
+func (s *personService) Watch(req *personsv1.WatchRequest, stream personsv1.PersonService_WatchServer) error {
+	if s.feed == nil {
+		return status.Error(codes.FailedPrecondition, "Event stream disabled")
+	}
+	filter := filterFromProto(req.GetFilter())
+	cursor := s.feed.Last()
+	if req.AfterId != nil {
+		cursor = req.GetAfterId()
+	}
+
+	if !s.feed.Join() {
+		logrus.Warn("Rejected stream client, limit reached")
+		return status.Error(codes.ResourceExhausted, "Too many stream clients")
+	}
+	defer s.feed.Leave()
+
+	ctx := stream.Context()
+	logrus.WithFields(logrus.Fields{
+		"from":      cursor,
+		"principal": contextPrincipal(ctx),
+	}).Info("Stream client connected")
+
+	for {
+		changed := s.feed.Changed()
+		events, next, err := s.feed.Since(ctx, cursor)
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			logrus.WithError(err).Error("Failed to read events for the stream")
+			return status.Error(codes.Internal, "Failed to read events")
+		}
+		cursor = next
+		for _, e := range events {
+			if !filter.Matches(e.Person) {
+				continue
+			}
+			if err := stream.Send(eventToProto(e)); err != nil {
+				return err
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.feed.Done():
+			return status.Error(codes.Unavailable, "Server is shutting down")
+		case <-changed:
+		}
+	}
+}
+
+// grpcError maps a repository error to a status, logging unexpected ones.
+// failure is the message for those.
+func grpcError(err error, failure string) error {
+	var invalid *repository.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return status.Error(codes.InvalidArgument, invalid.Error())
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "Person not found")
+	default:
+		logrus.WithError(err).Error(failure)
+		return status.Error(codes.Internal, failure)
+	}
+}
+
+func filterFromProto(f *personsv1.PersonFilter) repository.Filter {
+	return repository.Filter{
+		Name:        f.GetName(),
+		Surname:     f.GetSurname(),
+		Patronymic:  f.GetPatronymic(),
+		Age:         intPtr(f.Age),
+		Gender:      f.GetGender(),
+		Nationality: f.GetNationality(),
+	}
+}
+
+func personToProto(p models.Person) *personsv1.Person {
+	person := &personsv1.Person{
+		Id:              int64(p.ID),
+		Name:            p.Name,
+		Surname:         p.Surname,
+		Patronymic:      p.Patronymic,
+		Gender:          p.Gender,
+		Nationality:     p.Nationality,
+		NameLatin:       p.NameLatin,
+		SurnameLatin:    p.SurnameLatin,
+		PatronymicLatin: p.PatronymicLatin,
+	}
+	if p.Age != nil {
+		age := int32(*p.Age)
+		person.Age = &age
+	}
+	if e := p.Enrichment; e != nil {
+		person.Enrichment = &personsv1.Enrichment{
+			Age:         attributeToProto(e.Age),
+			Gender:      attributeToProto(e.Gender),
+			Nationality: attributeToProto(e.Nationality),
+		}
+	}
+	return person
+}
+
+func attributeToProto(a *models.AttributeEnrichment) *personsv1.AttributeEnrichment {
+	if a == nil {
+		return nil
+	}
+	attr := &personsv1.AttributeEnrichment{
+		Status:      a.Status,
+		Provider:    a.Provider,
+		Rule:        a.Rule,
+		Probability: a.Probability,
+		Reason:      a.Reason,
+	}
+	if a.Value != nil {
+		// Values are numbers or strings, which structpb always converts.
+		attr.Value, _ = structpb.NewValue(a.Value)
+	}
+	if a.Count != nil {
+		count := int64(*a.Count)
+		attr.Count = &count
+	}
+	return attr
+}
+
+func eventToProto(e models.PersonEvent) *personsv1.PersonEvent {
+	event := &personsv1.PersonEvent{
+		Id:         e.ID,
+		Type:       e.Type,
+		PersonId:   int64(e.PersonID),
+		OccurredAt: timestamppb.New(e.OccurredAt),
+	}
+	if e.Person != nil {
+		event.Person = personToProto(*e.Person)
+	}
+	return event
+}
+
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
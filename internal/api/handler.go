@@ -1,14 +1,31 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
 	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/names"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/outbox"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/repository"
 	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/service"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/webhooks"
 	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 
 	_ "github.com/Krchnk/EffectiveMobileFullNameTest/docs"
 	"github.com/swaggo/files"
@@ -16,23 +33,273 @@ import (
 )
 
 type Handler struct {
-	db     *sql.DB
-	enrich *service.EnrichmentService
+	db             *sql.DB
+	enrich         *service.EnrichmentService
+	checkProviders bool
+	providers      *providerChecks
+	jobs           *enrichmentJobs
+	persons        *repository.Persons
+	// migrations is the latest migration version the binary expects, or 0
+	// when it could not be read.
+	migrations uint
+	// subscriptions is nil when webhooks are disabled.
+	subscriptions *webhooks.Store
+	// feed is nil when the event stream is disabled.
+	feed      *outbox.Feed
+	heartbeat time.Duration
+	graphql   *graphql.Schema
+	limits    *rateLimiter
 }
 
-func StartServer(db *sql.DB, addr string) error {
+// StartServer serves the API, and the gRPC API when enabled, until SIGINT
+// or SIGTERM arrives, then stops accepting connections and waits up to
+// cfg.Server.ShutdownTimeout for in-flight requests and enrichment to
+// finish.
+func StartServer(db *sql.DB, cfg *config.Config) error {
+	enrich, err := service.NewEnrichmentService(cfg.Enrichment)
+	if err != nil {
+		return fmt.Errorf("configure enrichment: %w", err)
+	}
+
+	var sinks []outbox.Sink
+	if cfg.Outbox.Enabled {
+		sink, err := outbox.NewSink(cfg.Outbox)
+		if err != nil {
+			return fmt.Errorf("configure outbox: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	var subscriptions *webhooks.Store
+	if cfg.Webhooks.Enabled {
+		subscriptions = webhooks.NewStore(db)
+		sinks = append(sinks, webhooks.NewSink(db))
+	}
+	var sink outbox.Sink
+	if len(sinks) > 0 {
+		sink = outbox.Fanout(sinks...)
+		defer sink.Close()
+	}
+	store := repository.NewPersons(db, enrich, outbox.NewRecorder(cfg.Outbox.Enabled))
+	var feed *outbox.Feed
+	if cfg.Stream.Enabled {
+		feed, err = outbox.NewFeed(context.Background(), db, cfg.Stream)
+		if err != nil {
+			return fmt.Errorf("start event stream: %w", err)
+		}
+	}
+
+	rl := newRateLimiter(cfg.RateLimit, db)
 	r := gin.Default()
-	h := &Handler{db: db, enrich: &service.EnrichmentService{}}
+	h := &Handler{
+		db:             db,
+		migrations:     latestMigration(cfg.Database.MigrationsSource),
+		enrich:         enrich,
+		checkProviders: cfg.Health.CheckProviders,
+		providers:      &providerChecks{ttl: cfg.Health.ProvidersCacheTTL},
+		persons:        store,
+		jobs:           newEnrichmentJobs(store),
+		subscriptions:  subscriptions,
+		feed:           feed,
+		heartbeat:      cfg.Stream.Heartbeat,
+		graphql:        newGraphQLSchema(store),
+		limits:         rl,
+	}
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.GET("/persons", h.GetPersons)
-	r.POST("/persons", h.CreatePerson)
-	r.PATCH("/persons/:id", h.PatchPerson)
-	r.PUT("/persons/:id", h.UpdatePerson)
-	r.DELETE("/persons/:id", h.DeletePerson)
-
-	logrus.WithField("addr", addr).Info("Server starting")
-	return r.Run(addr)
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
+
+	protected := []gin.HandlerFunc{auditLog()}
+	var authenticators []auth.Authenticator
+	rbac := newRBAC(cfg.Auth)
+	if cfg.Auth.Enabled {
+		authenticators, err = newAuthenticators(cfg.Auth)
+		if err != nil {
+			return fmt.Errorf("configure authentication: %w", err)
+		}
+		protected = append(protected, authenticate(authenticators, rbac))
+	} else {
+		logrus.Warn("Authentication is disabled, /persons, /graphql, /admin and /metrics are open to every client")
+	}
+	persons := r.Group("/persons", protected...)
+	admin := r.Group("/admin", protected...)
+	admin.Use(requirePermission(auth.PermAdmin))
+	// GraphQL fields check their own permissions.
+	graph := r.Group("/graphql", protected...)
+
+	persons.GET("", requirePermission(auth.PermPersonsRead), rl.limit(limitRead), h.GetPersons)
+	persons.POST("", requirePermission(auth.PermPersonsWrite), rl.limit(limitEnrich), h.CreatePerson)
+	persons.POST("/parse", requirePermission(auth.PermPersonsRead), rl.limit(limitRead), h.ParsePerson)
+	persons.POST("/bulk", requirePermission(auth.PermPersonsWrite), rl.limit(limitEnrich), h.CreatePersons)
+	persons.GET("/stream", requirePermission(auth.PermPersonsRead), rl.limit(limitRead), h.StreamPersons)
+	persons.GET("/:id/declension", requirePermission(auth.PermPersonsRead), rl.limit(limitRead), h.GetPersonDeclension)
+	persons.PATCH("/:id", requirePermission(auth.PermPersonsWrite), rl.limit(limitWrite), h.PatchPerson)
+	persons.PUT("/:id", requirePermission(auth.PermPersonsWrite), rl.limit(limitWrite), h.UpdatePerson)
+	persons.DELETE("/:id", requirePermission(auth.PermPersonsDelete), rl.limit(limitWrite), h.DeletePerson)
+
+	graph.POST("", rl.limit(limitRead), h.GraphQL)
+
+	// The metrics include command line, memory and upstream failure details.
+	r.GET("/metrics", append(protected, requirePermission(auth.PermMetricsRead), h.Metrics)...)
+
+	admin.GET("/enrichment/quotas", h.GetEnrichmentQuotas)
+	admin.GET("/enrichment/offline", h.GetOfflineDataset)
+	admin.POST("/enrichment/offline/reload", h.ReloadOfflineDataset)
+	admin.POST("/enrichment/jobs", h.StartEnrichmentJob)
+	admin.GET("/enrichment/jobs", h.ListEnrichmentJobs)
+	admin.GET("/enrichment/jobs/:id", h.GetEnrichmentJob)
+	admin.POST("/webhooks", h.CreateWebhook)
+	admin.GET("/webhooks", h.ListWebhooks)
+	admin.GET("/webhooks/:id", h.GetWebhook)
+	admin.PUT("/webhooks/:id", h.UpdateWebhook)
+	admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+	admin.GET("/webhooks/:id/deliveries", h.ListWebhookDeliveries)
+
+	srv := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go reloadOnHangup(ctx, h.enrich)
+
+	// The outbox relay and the webhook worker stop with ctx; whatever they
+	// have not sent yet is sent after the next start.
+	var background sync.WaitGroup
+	if feed != nil {
+		// Streams never finish on their own, so Shutdown ends them.
+		srv.RegisterOnShutdown(feed.Close)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			feed.Run(ctx)
+		}()
+	}
+	if sink != nil {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			outbox.NewRelay(db, sink, cfg.Outbox).Run(ctx)
+		}()
+	}
+	if cfg.Webhooks.Enabled {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			webhooks.NewWorker(db, cfg.Webhooks).Run(ctx)
+		}()
+	}
+	backgroundDone := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(backgroundDone)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		logrus.WithField("addr", cfg.Server.Addr).Info("Server starting")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	var grpcServer *grpc.Server
+	grpcErrCh := make(chan error, 1)
+	if cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+		if err != nil {
+			srv.Close()
+			return fmt.Errorf("listen for gRPC: %w", err)
+		}
+		grpcServer = newGRPCServer(cfg.GRPC, store, feed, authenticators, rbac, rl)
+		go func() {
+			logrus.WithField("addr", cfg.GRPC.Addr).Info("gRPC server starting")
+			if err := grpcServer.Serve(lis); err != nil {
+				grpcErrCh <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case err := <-grpcErrCh:
+		srv.Close()
+		return fmt.Errorf("serve gRPC: %w", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	logrus.WithField("timeout", cfg.Server.ShutdownTimeout.String()).Info("Shutdown signal received, draining requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("Grace period expired, closing remaining connections")
+		srv.Close()
+	}
+
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	if err := h.jobs.Drain(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("Grace period expired, canceling re-enrichment jobs")
+	}
+
+	if err := h.enrich.Drain(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("Grace period expired before enrichment finished")
+	}
+
+	select {
+	case <-backgroundDone:
+	case <-shutdownCtx.Done():
+		logrus.Warn("Grace period expired before the outbox relay and webhook worker stopped")
+	}
+
+	logrus.Info("Server stopped")
+	return <-errCh
+}
+
+// stopGRPC lets in-flight calls finish until ctx is done and then closes
+// the remaining connections. Watch streams end when the feed is closed.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logrus.Warn("Grace period expired, closing remaining gRPC connections")
+		s.Stop()
+	}
+}
+
+// reloadOnHangup re-reads the offline dataset whenever SIGHUP arrives,
+// until ctx is done.
+func reloadOnHangup(ctx context.Context, enrich *service.EnrichmentService) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logrus.Info("SIGHUP received, reloading offline dataset")
+			if err := enrich.ReloadOffline(); err != nil {
+				logrus.WithError(err).Error("Failed to reload offline dataset")
+			}
+		}
+	}
 }
 
 // GetPersons godoc
@@ -51,18 +318,17 @@ func StartServer(db *sql.DB, addr string) error {
 // @Param nationality query string false "Filter by nationality"
 // @Success 200 {array} models.Person
 // @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /persons [get]
 func (h *Handler) GetPersons(c *gin.Context) {
 	logrus.Info("Received GET /persons request")
 	limitStr := c.DefaultQuery("limit", "10")
 	offsetStr := c.DefaultQuery("offset", "0")
-	nameFilter := c.Query("name")
-	surnameFilter := c.Query("surname")
-	patronymicFilter := c.Query("patronymic")
-	ageStr := c.Query("age")
-	genderFilter := c.Query("gender")
-	nationalityFilter := c.Query("nationality")
 
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
@@ -78,79 +344,17 @@ func (h *Handler) GetPersons(c *gin.Context) {
 		return
 	}
 
-	var ageFilter *int
-	if ageStr != "" {
-		age, err := strconv.Atoi(ageStr)
-		if err != nil {
-			logrus.WithField("age", ageStr).Error("Invalid age parameter")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid age parameter"})
-			return
-		}
-		ageFilter = &age
-	}
-
-	query := "SELECT id, name, surname, patronymic, age, gender, nationality FROM persons WHERE 1=1"
-	var args []interface{}
-	argCount := 1
-
-	if nameFilter != "" {
-		query += " AND name = $" + strconv.Itoa(argCount)
-		args = append(args, nameFilter)
-		argCount++
-	}
-	if surnameFilter != "" {
-		query += " AND surname = $" + strconv.Itoa(argCount)
-		args = append(args, surnameFilter)
-		argCount++
-	}
-	if patronymicFilter != "" {
-		query += " AND patronymic = $" + strconv.Itoa(argCount)
-		args = append(args, patronymicFilter)
-		argCount++
-	}
-	if ageFilter != nil {
-		query += " AND age = $" + strconv.Itoa(argCount)
-		args = append(args, *ageFilter)
-		argCount++
-	}
-	if genderFilter != "" {
-		query += " AND gender = $" + strconv.Itoa(argCount)
-		args = append(args, genderFilter)
-		argCount++
-	}
-	if nationalityFilter != "" {
-		query += " AND nationality = $" + strconv.Itoa(argCount)
-		args = append(args, nationalityFilter)
-		argCount++
+	filter, ok := personFilter(c)
+	if !ok {
+		return
 	}
 
-	query += " ORDER BY id LIMIT $" + strconv.Itoa(argCount) + " OFFSET $" + strconv.Itoa(argCount+1)
-	args = append(args, limit, offset)
-
-	logrus.WithFields(logrus.Fields{
-		"query": query,
-		"args":  args,
-	}).Debug("Executing database query for persons")
-
-	rows, err := h.db.Query(query, args...)
+	persons, err := h.persons.List(c.Request.Context(), filter, limit, offset)
 	if err != nil {
 		logrus.WithError(err).Error("Database query failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
-	defer rows.Close()
-
-	var persons []models.Person
-	for rows.Next() {
-		var p models.Person
-		err := rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality)
-		if err != nil {
-			logrus.WithError(err).Error("Failed to scan row")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
-		}
-		persons = append(persons, p)
-	}
 
 	logrus.WithField("count", len(persons)).Info("Successfully retrieved persons")
 	c.JSON(http.StatusOK, persons)
@@ -158,14 +362,19 @@ func (h *Handler) GetPersons(c *gin.Context) {
 
 // CreatePerson godoc
 // @Summary Create a new person
-// @Description Creates a new person and enriches their data with age, gender, and nationality
+// @Description Creates a new person and enriches their data with age, gender, and nationality. The name may be sent as name, surname and patronymic or as a single full_name, split as by POST /persons/parse.
 // @Tags persons
 // @Accept json
 // @Produce json
 // @Param person body models.PersonRequest true "Person data to create"
 // @Success 201 {object} models.Person
 // @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /persons [post]
 func (h *Handler) CreatePerson(c *gin.Context) {
 	logrus.Info("Received POST /persons request")
@@ -178,33 +387,92 @@ func (h *Handler) CreatePerson(c *gin.Context) {
 
 	logrus.WithField("request", req).Debug("Parsed person request")
 
-	person := models.Person{
-		Name:       req.Name,
-		Surname:    req.Surname,
-		Patronymic: req.Patronymic,
+	person, err := h.persons.Create(c.Request.Context(), req)
+	if !personStored(c, 0, err, "Failed to create person") {
+		return
 	}
 
-	logrus.WithField("name", person.Name).Debug("Starting person enrichment")
-	if err := h.enrich.EnrichPerson(&person); err != nil {
-		logrus.WithError(err).Warn("Failed to enrich person data")
-	}
+	logrus.WithFields(logrus.Fields{
+		"id":        person.ID,
+		"principal": principalName(c),
+	}).Info("Person successfully created")
+	c.JSON(http.StatusCreated, person)
+}
 
-	query := `
-		INSERT INTO persons (name, surname, patronymic, age, gender, nationality)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id`
+// ParsePerson godoc
+// @Summary Split a full name into its parts
+// @Description Splits a full name such as "Иванов Иван Иванович" or "Ivan Ivanovich Ivanov" into name, surname and patronymic. The patronymic is recognized by its ending and fixes the order; without one, surname endings and the script decide. Nothing is stored.
+// @Tags persons
+// @Accept json
+// @Produce json
+// @Param request body models.ParseRequest true "Full name"
+// @Success 200 {object} models.ParsedName
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 422 {object} models.ErrorResponse "Full name could not be split"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /persons/parse [post]
+func (h *Handler) ParsePerson(c *gin.Context) {
+	logrus.Info("Received POST /persons/parse request")
+	var req models.ParseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
 
-	logrus.WithField("person", person).Debug("Inserting person into database")
-	err := h.db.QueryRow(query, person.Name, person.Surname, person.Patronymic,
-		person.Age, person.Gender, person.Nationality).Scan(&person.ID)
+	parsed, err := names.Parse(req.FullName)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to insert person into database")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create person"})
+		logrus.WithError(err).Debug("Failed to parse full name")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		return
 	}
 
-	logrus.WithField("id", person.ID).Info("Person successfully created")
-	c.JSON(http.StatusCreated, person)
+	logrus.WithFields(logrus.Fields{
+		"order":      parsed.Order,
+		"confidence": parsed.Confidence,
+	}).Debug("Full name parsed")
+	c.JSON(http.StatusOK, parsedName(parsed))
+}
+
+// CreatePersons godoc
+// @Summary Import persons in bulk
+// @Description Creates up to 1000 persons in one transaction. Distinct names are enriched together in multi-name requests of up to 10 names.
+// @Tags persons
+// @Accept json
+// @Produce json
+// @Param persons body models.BulkPersonRequest true "Persons to create"
+// @Success 201 {array} models.Person
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /persons/bulk [post]
+func (h *Handler) CreatePersons(c *gin.Context) {
+	logrus.Info("Received POST /persons/bulk request")
+	var req models.BulkPersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	persons, err := h.persons.CreateMany(c.Request.Context(), req.Persons)
+	if !personStored(c, 0, err, "Failed to create persons") {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"count":     len(persons),
+		"principal": principalName(c),
+	}).Info("Persons successfully created")
+	c.JSON(http.StatusCreated, persons)
 }
 
 // PatchPerson godoc
@@ -217,16 +485,18 @@ func (h *Handler) CreatePerson(c *gin.Context) {
 // @Param person body models.PersonPatch true "Fields to update"
 // @Success 200 {object} models.Person
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
 // @Failure 404 {object} models.ErrorResponse "Person not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /persons/{id} [patch]
 func (h *Handler) PatchPerson(c *gin.Context) {
 	logrus.Info("Received PATCH /persons/:id request")
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		logrus.WithField("id", idStr).Error("Invalid ID parameter")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	id, ok := personID(c)
+	if !ok {
 		return
 	}
 
@@ -242,76 +512,15 @@ func (h *Handler) PatchPerson(c *gin.Context) {
 		"patch": patch,
 	}).Debug("Parsed patch person request")
 
-	query := "UPDATE persons SET "
-	var args []interface{}
-	argCount := 1
-
-	if patch.Name != nil {
-		query += "name = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Name)
-		argCount++
-	}
-	if patch.Surname != nil {
-		query += "surname = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Surname)
-		argCount++
-	}
-	if patch.Patronymic != nil {
-		query += "patronymic = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Patronymic)
-		argCount++
-	}
-	if patch.Age != nil {
-		query += "age = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Age)
-		argCount++
-	}
-	if patch.Gender != nil {
-		query += "gender = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Gender)
-		argCount++
-	}
-	if patch.Nationality != nil {
-		query += "nationality = $" + strconv.Itoa(argCount) + ", "
-		args = append(args, *patch.Nationality)
-		argCount++
-	}
-
-	if argCount == 1 {
-		logrus.WithField("id", id).Error("No fields to update")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+	updatedPerson, err := h.persons.Patch(c.Request.Context(), id, patch)
+	if !personStored(c, id, err, "Failed to update person") {
 		return
 	}
 
-	query = query[:len(query)-2]
-	query += " WHERE id = $" + strconv.Itoa(argCount)
-	args = append(args, id)
-
-	logrus.WithField("id", id).Debug("Updating person in database")
-	result, err := h.db.Exec(query, args...)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to update person")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		logrus.WithField("id", id).Warn("Person not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
-		return
-	}
-
-	var updatedPerson models.Person
-	err = h.db.QueryRow("SELECT id, name, surname, patronymic, age, gender, nationality FROM persons WHERE id = $1", id).
-		Scan(&updatedPerson.ID, &updatedPerson.Name, &updatedPerson.Surname, &updatedPerson.Patronymic, &updatedPerson.Age, &updatedPerson.Gender, &updatedPerson.Nationality)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to fetch updated person")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated person"})
-		return
-	}
-
-	logrus.WithField("id", id).Info("Person successfully updated")
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": principalName(c),
+	}).Info("Person successfully updated")
 	c.JSON(http.StatusOK, updatedPerson)
 }
 
@@ -325,16 +534,18 @@ func (h *Handler) PatchPerson(c *gin.Context) {
 // @Param person body models.Person true "Updated person data"
 // @Success 200 {object} models.Person
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
 // @Failure 404 {object} models.ErrorResponse "Person not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /persons/{id} [put]
 func (h *Handler) UpdatePerson(c *gin.Context) {
 	logrus.Info("Received PUT /persons/:id request")
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		logrus.WithField("id", idStr).Error("Invalid ID parameter")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+	id, ok := personID(c)
+	if !ok {
 		return
 	}
 
@@ -344,36 +555,81 @@ func (h *Handler) UpdatePerson(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
-	person.ID = id
 
 	logrus.WithFields(logrus.Fields{
 		"id":     id,
 		"person": person,
 	}).Debug("Parsed update person request")
 
-	query := `
-		UPDATE persons 
-		SET name = $1, surname = $2, patronymic = $3, age = $4, gender = $5, nationality = $6
-		WHERE id = $7`
+	person, err := h.persons.Update(c.Request.Context(), id, person)
+	if !personStored(c, id, err, "Failed to update person") {
+		return
+	}
 
-	logrus.WithField("id", id).Debug("Updating person in database")
-	result, err := h.db.Exec(query, person.Name, person.Surname, person.Patronymic,
-		person.Age, person.Gender, person.Nationality, person.ID)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to update person")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update person"})
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": principalName(c),
+	}).Info("Person successfully updated")
+	c.JSON(http.StatusOK, person)
+}
+
+// GetPersonDeclension godoc
+// @Summary Decline a person's full name
+// @Description Returns the stored full name in all six Russian cases. The paradigms follow the person's gender or, when it is not male or female, the gender implied by the patronymic or surname. Names in Latin script and indeclinable surnames such as Шевченко stay unchanged.
+// @Tags persons
+// @Produce json
+// @Param id path int true "Person ID"
+// @Success 200 {object} models.Declension
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Person not found"
+// @Failure 422 {object} models.ErrorResponse "Gender unknown"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /persons/{id}/declension [get]
+func (h *Handler) GetPersonDeclension(c *gin.Context) {
+	logrus.Info("Received GET /persons/:id/declension request")
+	id, ok := personID(c)
+	if !ok {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		logrus.WithField("id", id).Warn("Person not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
+	person, err := h.persons.Get(c.Request.Context(), id)
+	if !personStored(c, id, err, "Failed to fetch person") {
 		return
 	}
 
-	logrus.WithField("id", id).Info("Person successfully updated")
-	c.JSON(http.StatusOK, person)
+	var patronymic string
+	if person.Patronymic != nil {
+		patronymic = *person.Patronymic
+	}
+	result := models.Declension{GenderSource: "person"}
+	if person.Gender != nil && (*person.Gender == names.Male || *person.Gender == names.Female) {
+		result.Gender = *person.Gender
+	} else if guess, ok := names.InferGender(patronymic, person.Surname); ok {
+		result.Gender, result.GenderSource = guess.Gender, "rules"
+	} else {
+		logrus.WithField("id", id).Warn("Gender unknown, cannot decline name")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Gender is unknown; set it to male or female to decline the name"})
+		return
+	}
+
+	d := names.Decline(person.Surname, person.Name, patronymic, result.Gender)
+	result.Nominative = declinedName(d.Nominative)
+	result.Genitive = declinedName(d.Genitive)
+	result.Dative = declinedName(d.Dative)
+	result.Accusative = declinedName(d.Accusative)
+	result.Instrumental = declinedName(d.Instrumental)
+	result.Prepositional = declinedName(d.Prepositional)
+
+	logrus.WithFields(logrus.Fields{
+		"id":     id,
+		"gender": result.Gender,
+	}).Debug("Declined person name")
+	c.JSON(http.StatusOK, result)
 }
 
 // DeletePerson godoc
@@ -383,35 +639,85 @@ func (h *Handler) UpdatePerson(c *gin.Context) {
 // @Param id path int true "Person ID"
 // @Success 204 "Person deleted"
 // @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
 // @Failure 404 {object} models.ErrorResponse "Person not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /persons/{id} [delete]
 func (h *Handler) DeletePerson(c *gin.Context) {
 	logrus.Info("Received DELETE /persons/:id request")
+	id, ok := personID(c)
+	if !ok {
+		return
+	}
+
+	_, err := h.persons.Delete(c.Request.Context(), id)
+	if !personStored(c, id, err, "Failed to delete person") {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": principalName(c),
+	}).Info("Person successfully deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// personFilter reads the GetPersons filters from the query, writing the
+// error response when one is invalid.
+func personFilter(c *gin.Context) (repository.Filter, bool) {
+	filter := repository.Filter{
+		Name:        c.Query("name"),
+		Surname:     c.Query("surname"),
+		Patronymic:  c.Query("patronymic"),
+		Gender:      c.Query("gender"),
+		Nationality: c.Query("nationality"),
+	}
+	if ageStr := c.Query("age"); ageStr != "" {
+		age, err := strconv.Atoi(ageStr)
+		if err != nil {
+			logrus.WithField("age", ageStr).Error("Invalid age parameter")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid age parameter"})
+			return repository.Filter{}, false
+		}
+		filter.Age = &age
+	}
+	return filter, true
+}
+
+// personID parses the person ID path parameter, writing the error
+// response when it is invalid.
+func personID(c *gin.Context) (int, bool) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		logrus.WithField("id", idStr).Error("Invalid ID parameter")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
-		return
-	}
-
-	logrus.WithField("id", id).Debug("Preparing to delete person")
-	query := "DELETE FROM persons WHERE id = $1"
-	result, err := h.db.Exec(query, id)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to delete person")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete person"})
-		return
+		return 0, false
 	}
+	return id, true
+}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+// personStored writes the error response for a repository error and
+// reports whether there was none. failure is the message for unexpected
+// errors.
+func personStored(c *gin.Context, id int, err error, failure string) bool {
+	var invalid *repository.ValidationError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &invalid):
+		logrus.WithError(err).Error("Invalid person")
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Error()})
+	case errors.Is(err, sql.ErrNoRows):
 		logrus.WithField("id", id).Warn("Person not found")
 		c.JSON(http.StatusNotFound, gin.H{"error": "Person not found"})
-		return
+	default:
+		logrus.WithError(err).Error(failure)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failure})
 	}
-
-	logrus.WithField("id", id).Info("Person successfully deleted")
-	c.Status(http.StatusNoContent)
+	return false
 }
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/db"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const readinessTimeout = 3 * time.Second
+
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Reports that the process is up and serving requests
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Router /healthz [get]
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, models.HealthResponse{Status: "ok"})
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Checks database connectivity, migration state (not ready when dirty or behind the migrations of the binary) and, when enabled, enrichment provider reachability, which is cached for the providers cache TTL
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.ReadinessResponse "Ready or degraded"
+// @Failure 503 {object} models.ReadinessResponse "Not ready"
+// @Router /readyz [get]
+func (h *Handler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := models.ReadinessResponse{
+		Status:       "ready",
+		Dependencies: make(map[string]models.DependencyStatus),
+	}
+
+	dbStatus := h.checkDatabase(ctx)
+	resp.Dependencies["database"] = dbStatus
+
+	if dbStatus.Status == "up" {
+		resp.Dependencies["migrations"] = h.checkMigrations(ctx)
+	} else {
+		resp.Dependencies["migrations"] = models.DependencyStatus{Status: "skipped", Details: "database is down"}
+	}
+
+	for _, name := range []string{"database", "migrations"} {
+		if resp.Dependencies[name].Status != "up" {
+			resp.Status = "not_ready"
+		}
+	}
+
+	if h.checkProviders {
+		for name, status := range h.providers.statuses(ctx, h.checkEnrichmentProviders) {
+			resp.Dependencies["enrichment:"+name] = status
+			// Enrichment failures only leave fields empty, so a provider
+			// outage degrades the service without taking it out of rotation.
+			if status.Status != "up" && resp.Status == "ready" {
+				resp.Status = "degraded"
+			}
+		}
+	}
+
+	code := http.StatusOK
+	if resp.Status == "not_ready" {
+		code = http.StatusServiceUnavailable
+		logrus.WithField("dependencies", resp.Dependencies).Warn("Readiness check failed")
+	}
+	c.JSON(code, resp)
+}
+
+func (h *Handler) checkDatabase(ctx context.Context) models.DependencyStatus {
+	if err := h.db.PingContext(ctx); err != nil {
+		return models.DependencyStatus{Status: "down", Error: err.Error()}
+	}
+	return models.DependencyStatus{Status: "up"}
+}
+
+func (h *Handler) checkMigrations(ctx context.Context) models.DependencyStatus {
+	version, dirty, err := db.MigrationStatus(ctx, h.db)
+	if err != nil {
+		return models.DependencyStatus{Status: "down", Error: err.Error()}
+	}
+	details := "version " + strconv.FormatUint(uint64(version), 10)
+	if dirty {
+		return models.DependencyStatus{Status: "down", Details: details, Error: "migration state is dirty"}
+	}
+	// A newer schema is fine: an older replica is still running during a
+	// rollout. An older one lacks what this binary queries.
+	if version < h.migrations {
+		return models.DependencyStatus{
+			Status:  "down",
+			Details: details,
+			Error:   "pending migrations, latest is version " + strconv.FormatUint(uint64(h.migrations), 10),
+		}
+	}
+	return models.DependencyStatus{Status: "up", Details: details}
+}
+
+// latestMigration is the version checkMigrations expects the database at.
+func latestMigration(source string) uint {
+	version, err := db.LatestMigration(source)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to read the latest migration version, readiness will not compare it")
+	}
+	return version
+}
+
+// providerChecks reuses the last provider check for ttl. The lock is held
+// during a check, so concurrent probes wait for it instead of repeating it.
+type providerChecks struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	last      map[string]models.DependencyStatus
+}
+
+func (p *providerChecks) statuses(ctx context.Context, check func(context.Context) map[string]models.DependencyStatus) map[string]models.DependencyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.last != nil && time.Since(p.checkedAt) < p.ttl {
+		return p.last
+	}
+	p.last = check(ctx)
+	p.checkedAt = time.Now()
+	return p.last
+}
+
+func (h *Handler) checkEnrichmentProviders(ctx context.Context) map[string]models.DependencyStatus {
+	statuses := make(map[string]models.DependencyStatus)
+	for name, err := range h.enrich.CheckProviders(ctx) {
+		if err != nil {
+			statuses[name] = models.DependencyStatus{Status: "down", Error: err.Error()}
+			continue
+		}
+		statuses[name] = models.DependencyStatus{Status: "up"}
+	}
+	return statuses
+}
+
+// Metrics godoc
+// @Summary Runtime and enrichment metrics
+// @Description Exposes expvar metrics, including enrichment request, retry and failure counters and circuit breaker states
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /metrics [get]
+func (h *Handler) Metrics(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// reEnrichPageSize is how many persons a re-enrichment job reads,
+	// enriches and writes back per step.
+	reEnrichPageSize = 100
+	// maxFinishedJobs bounds the history of finished jobs kept in memory.
+	maxFinishedJobs = 50
+)
+
+// enrichmentJobs runs re-enrichment jobs in the background and keeps their
+// progress in memory, so the history is lost on restart.
+type enrichmentJobs struct {
+	persons *repository.Persons
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	nextID int
+	jobs   map[int]*models.EnrichmentJob
+}
+
+func newEnrichmentJobs(persons *repository.Persons) *enrichmentJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &enrichmentJobs{
+		persons: persons,
+		ctx:     ctx,
+		cancel:  cancel,
+		jobs:    make(map[int]*models.EnrichmentJob),
+	}
+}
+
+func (j *enrichmentJobs) start(req models.ReEnrichRequest) models.EnrichmentJob {
+	j.mu.Lock()
+	j.nextID++
+	job := &models.EnrichmentJob{
+		ID:          j.nextID,
+		Status:      "running",
+		OnlyMissing: req.OnlyMissing,
+		StartedAt:   time.Now(),
+	}
+	j.jobs[job.ID] = job
+	j.pruneLocked()
+	snapshot := *job
+	j.mu.Unlock()
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		j.run(job, req)
+	}()
+	return snapshot
+}
+
+func (j *enrichmentJobs) get(id int) (models.EnrichmentJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return models.EnrichmentJob{}, false
+	}
+	return *job, true
+}
+
+func (j *enrichmentJobs) list() []models.EnrichmentJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	jobs := make([]models.EnrichmentJob, 0, len(j.jobs))
+	for _, job := range j.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].ID > jobs[b].ID })
+	return jobs
+}
+
+// pruneLocked forgets the oldest finished jobs beyond maxFinishedJobs.
+func (j *enrichmentJobs) pruneLocked() {
+	var finished []int
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Ints(finished)
+	for _, id := range finished[:len(finished)-maxFinishedJobs] {
+		delete(j.jobs, id)
+	}
+}
+
+// Drain blocks until every running job has finished. When ctx expires
+// first the jobs are canceled and stop after their current page.
+func (j *enrichmentJobs) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		j.cancel()
+		return ctx.Err()
+	}
+}
+
+func (j *enrichmentJobs) run(job *models.EnrichmentJob, req models.ReEnrichRequest) {
+	log := logrus.WithFields(logrus.Fields{
+		"job":          job.ID,
+		"only_missing": req.OnlyMissing,
+	})
+	log.Info("Re-enrichment job started")
+
+	err := j.process(job, req)
+
+	j.mu.Lock()
+	now := time.Now()
+	job.FinishedAt = &now
+	switch {
+	case err == nil:
+		job.Status = "completed"
+	case errors.Is(err, context.Canceled):
+		job.Status = "canceled"
+	default:
+		job.Status = "failed"
+		job.Error = err.Error()
+	}
+	status, processed, updated := job.Status, job.Processed, job.Updated
+	j.mu.Unlock()
+
+	log = log.WithFields(logrus.Fields{
+		"status":    status,
+		"processed": processed,
+		"updated":   updated,
+	})
+	if status == "failed" {
+		log.WithError(err).Error("Re-enrichment job failed")
+		return
+	}
+	log.Info("Re-enrichment job finished")
+}
+
+// process walks the selected persons in id order, one page at a time, so
+// a large table is never held in memory.
+func (j *enrichmentJobs) process(job *models.EnrichmentJob, req models.ReEnrichRequest) error {
+	after := 0
+	for {
+		if err := j.ctx.Err(); err != nil {
+			return err
+		}
+
+		persons, err := j.persons.ReenrichPage(j.ctx, req, after, reEnrichPageSize)
+		if err != nil {
+			return err
+		}
+		if len(persons) == 0 {
+			return nil
+		}
+		after = persons[len(persons)-1].ID
+
+		updated, err := j.persons.Reenrich(j.ctx, persons, req.OnlyMissing)
+		if err != nil {
+			return err
+		}
+
+		j.mu.Lock()
+		job.Processed += len(persons)
+		job.Updated += updated
+		j.mu.Unlock()
+	}
+}
+
+// StartEnrichmentJob godoc
+// @Summary Start a re-enrichment job
+// @Description Re-enriches stored persons in the background using batched multi-name lookups. Poll the returned job for progress.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param job body models.ReEnrichRequest false "Persons to re-enrich"
+// @Success 202 {object} models.EnrichmentJob
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/jobs [post]
+func (h *Handler) StartEnrichmentJob(c *gin.Context) {
+	var req models.ReEnrichRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logrus.WithError(err).Error("Invalid request body")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	job := h.jobs.start(req)
+	logrus.WithFields(logrus.Fields{
+		"job":       job.ID,
+		"principal": principalName(c),
+	}).Info("Re-enrichment job accepted")
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListEnrichmentJobs godoc
+// @Summary List re-enrichment jobs
+// @Description Returns running and recently finished re-enrichment jobs, newest first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.EnrichmentJob
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/jobs [get]
+func (h *Handler) ListEnrichmentJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.jobs.list())
+}
+
+// GetEnrichmentJob godoc
+// @Summary Get a re-enrichment job
+// @Description Returns the progress of a re-enrichment job
+// @Tags admin
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} models.EnrichmentJob
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/enrichment/jobs/{id} [get]
+func (h *Handler) GetEnrichmentJob(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logrus.WithField("id", idStr).Error("Invalid ID parameter")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	job, ok := h.jobs.get(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
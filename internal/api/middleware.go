@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func newAuthenticators(cfg config.AuthConfig) ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if len(cfg.APIKeys) > 0 {
+		keys := make([]auth.APIKey, 0, len(cfg.APIKeys))
+		for _, k := range cfg.APIKeys {
+			keys = append(keys, auth.APIKey{Name: k.Name, Hash: k.Hash, Roles: k.Roles})
+		}
+		authenticators = append(authenticators, auth.NewAPIKeyAuthenticator(cfg.APIKeyHeader, keys))
+	}
+	if cfg.JWT.HMACSecret != "" || cfg.JWT.JWKSFile != "" {
+		jwtAuth, err := auth.NewJWTAuthenticator(auth.JWTOptions{
+			HMACSecret: cfg.JWT.HMACSecret,
+			JWKSFile:   cfg.JWT.JWKSFile,
+			Issuer:     cfg.JWT.Issuer,
+			Audience:   cfg.JWT.Audience,
+			RolesClaim: cfg.JWT.RolesClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwtAuth)
+	}
+	return authenticators, nil
+}
+
+func newRBAC(cfg config.AuthConfig) *auth.RBAC {
+	roles := make(map[string][]auth.Permission, len(cfg.Roles))
+	for role, perms := range cfg.Roles {
+		for _, perm := range perms {
+			// Permissions were checked by config.Validate.
+			p, _ := auth.ParsePermission(perm)
+			roles[role] = append(roles[role], p)
+		}
+	}
+	return auth.NewRBAC(roles)
+}
+
+// authenticate tries each authenticator in turn, resolves the principal's
+// permissions and stores it in the request context, where handlers read it
+// with auth.FromContext.
+func authenticate(authenticators []auth.Authenticator, rbac *auth.RBAC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, a := range authenticators {
+			principal, err := a.Authenticate(c.Request)
+			if errors.Is(err, auth.ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("path", c.Request.URL.Path).Warn("Authentication failed")
+				c.Header("WWW-Authenticate", `Bearer realm="persons"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+				return
+			}
+
+			principal.Permissions = rbac.Permissions(principal.Roles)
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), principal))
+			c.Next()
+			return
+		}
+
+		logrus.WithField("path", c.Request.URL.Path).Warn("Request without credentials")
+		c.Header("WWW-Authenticate", `Bearer realm="persons"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+	}
+}
+
+// requirePermission rejects principals lacking perm with 403. Requests
+// without a principal only get here when authentication is disabled.
+func requirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := auth.FromContext(c.Request.Context())
+		if p == nil || p.Can(perm) {
+			c.Next()
+			return
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"principal":  p.Subject,
+			"roles":      p.Roles,
+			"permission": perm,
+			"path":       c.Request.URL.Path,
+		}).Warn("Permission denied")
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ForbiddenResponse{
+			Error:              "Forbidden",
+			Detail:             "principal " + p.Subject + " lacks permission " + string(perm),
+			RequiredPermission: string(perm),
+		})
+	}
+}
+
+// auditLog records who called which endpoint and with what outcome.
+func auditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		fields := logrus.Fields{
+			"audit":     true,
+			"method":    c.Request.Method,
+			"route":     c.FullPath(),
+			"status":    c.Writer.Status(),
+			"client_ip": c.ClientIP(),
+			"principal": "anonymous",
+		}
+		if id := c.Param("id"); id != "" {
+			fields["person_id"] = id
+		}
+		if p := auth.FromContext(c.Request.Context()); p != nil {
+			fields["principal"] = p.Subject
+			fields["auth_method"] = p.Method
+		}
+		logrus.WithFields(fields).Info("Audit")
+	}
+}
+
+func principalName(c *gin.Context) string {
+	return contextPrincipal(c.Request.Context())
+}
+
+// contextPrincipal names the caller stored in ctx, for logs.
+func contextPrincipal(ctx context.Context) string {
+	if p := auth.FromContext(ctx); p != nil {
+		return p.Subject
+	}
+	return "anonymous"
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/names"
+)
+
+func parsedName(p names.ParsedName) models.ParsedName {
+	parsed := models.ParsedName{
+		Name:       p.Name,
+		Surname:    p.Surname,
+		Order:      p.Order,
+		Confidence: p.Confidence,
+		Reason:     p.Reason,
+	}
+	if p.Patronymic != "" {
+		parsed.Patronymic = &p.Patronymic
+	}
+	return parsed
+}
+
+func declinedName(d names.DeclinedName) models.DeclinedName {
+	declined := models.DeclinedName{Surname: d.Surname, Name: d.Name, FullName: d.Full()}
+	if d.Patronymic != "" {
+		declined.Patronymic = &d.Patronymic
+	}
+	return declined
+}
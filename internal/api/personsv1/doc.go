@@ -0,0 +1,7 @@
+// Package personsv1 holds the Go code generated from
+// proto/persons/v1/persons.proto. Regenerate it with go generate after
+// changing the definition; protoc, protoc-gen-go and protoc-gen-go-grpc
+// must be on the PATH.
+package personsv1
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/Krchnk/EffectiveMobileFullNameTest --go-grpc_out=../../.. --go-grpc_opt=module=github.com/Krchnk/EffectiveMobileFullNameTest persons/v1/persons.proto
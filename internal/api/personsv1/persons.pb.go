@@ -0,0 +1,1269 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: persons/v1/persons.proto
+
+// The person records of the REST API, for internal services. The methods
+// behave like their REST counterparts: names are normalized, new persons
+// are enriched and every change is recorded in the outbox.
+
+package personsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Person struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name       string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Surname    string                 `protobuf:"bytes,3,opt,name=surname,proto3" json:"surname,omitempty"`
+	Patronymic *string                `protobuf:"bytes,4,opt,name=patronymic,proto3,oneof" json:"patronymic,omitempty"`
+	Age        *int32                 `protobuf:"varint,5,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	// male, female or other.
+	Gender      *string `protobuf:"bytes,6,opt,name=gender,proto3,oneof" json:"gender,omitempty"`
+	Nationality *string `protobuf:"bytes,7,opt,name=nationality,proto3,oneof" json:"nationality,omitempty"`
+	// The Latin transliterations of the name parts.
+	NameLatin       *string `protobuf:"bytes,8,opt,name=name_latin,json=nameLatin,proto3,oneof" json:"name_latin,omitempty"`
+	SurnameLatin    *string `protobuf:"bytes,9,opt,name=surname_latin,json=surnameLatin,proto3,oneof" json:"surname_latin,omitempty"`
+	PatronymicLatin *string `protobuf:"bytes,10,opt,name=patronymic_latin,json=patronymicLatin,proto3,oneof" json:"patronymic_latin,omitempty"`
+	// How age, gender and nationality were predicted.
+	Enrichment    *Enrichment `protobuf:"bytes,11,opt,name=enrichment,proto3" json:"enrichment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Person) Reset() {
+	*x = Person{}
+	mi := &file_persons_v1_persons_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Person) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Person) ProtoMessage() {}
+
+func (x *Person) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Person.ProtoReflect.Descriptor instead.
+func (*Person) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Person) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Person) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Person) GetSurname() string {
+	if x != nil {
+		return x.Surname
+	}
+	return ""
+}
+
+func (x *Person) GetPatronymic() string {
+	if x != nil && x.Patronymic != nil {
+		return *x.Patronymic
+	}
+	return ""
+}
+
+func (x *Person) GetAge() int32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+func (x *Person) GetGender() string {
+	if x != nil && x.Gender != nil {
+		return *x.Gender
+	}
+	return ""
+}
+
+func (x *Person) GetNationality() string {
+	if x != nil && x.Nationality != nil {
+		return *x.Nationality
+	}
+	return ""
+}
+
+func (x *Person) GetNameLatin() string {
+	if x != nil && x.NameLatin != nil {
+		return *x.NameLatin
+	}
+	return ""
+}
+
+func (x *Person) GetSurnameLatin() string {
+	if x != nil && x.SurnameLatin != nil {
+		return *x.SurnameLatin
+	}
+	return ""
+}
+
+func (x *Person) GetPatronymicLatin() string {
+	if x != nil && x.PatronymicLatin != nil {
+		return *x.PatronymicLatin
+	}
+	return ""
+}
+
+func (x *Person) GetEnrichment() *Enrichment {
+	if x != nil {
+		return x.Enrichment
+	}
+	return nil
+}
+
+type Enrichment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Age           *AttributeEnrichment   `protobuf:"bytes,1,opt,name=age,proto3" json:"age,omitempty"`
+	Gender        *AttributeEnrichment   `protobuf:"bytes,2,opt,name=gender,proto3" json:"gender,omitempty"`
+	Nationality   *AttributeEnrichment   `protobuf:"bytes,3,opt,name=nationality,proto3" json:"nationality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Enrichment) Reset() {
+	*x = Enrichment{}
+	mi := &file_persons_v1_persons_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Enrichment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Enrichment) ProtoMessage() {}
+
+func (x *Enrichment) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Enrichment.ProtoReflect.Descriptor instead.
+func (*Enrichment) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Enrichment) GetAge() *AttributeEnrichment {
+	if x != nil {
+		return x.Age
+	}
+	return nil
+}
+
+func (x *Enrichment) GetGender() *AttributeEnrichment {
+	if x != nil {
+		return x.Gender
+	}
+	return nil
+}
+
+func (x *Enrichment) GetNationality() *AttributeEnrichment {
+	if x != nil {
+		return x.Nationality
+	}
+	return nil
+}
+
+type AttributeEnrichment struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// accepted, low_confidence, rejected or unavailable.
+	Status   string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Provider string `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	// The name ending that decided a rule-based prediction.
+	Rule string `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`
+	// The prediction, also when it was rejected.
+	Value         *structpb.Value `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Probability   *float64        `protobuf:"fixed64,5,opt,name=probability,proto3,oneof" json:"probability,omitempty"`
+	Count         *int64          `protobuf:"varint,6,opt,name=count,proto3,oneof" json:"count,omitempty"`
+	Reason        string          `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttributeEnrichment) Reset() {
+	*x = AttributeEnrichment{}
+	mi := &file_persons_v1_persons_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttributeEnrichment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttributeEnrichment) ProtoMessage() {}
+
+func (x *AttributeEnrichment) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttributeEnrichment.ProtoReflect.Descriptor instead.
+func (*AttributeEnrichment) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AttributeEnrichment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AttributeEnrichment) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *AttributeEnrichment) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *AttributeEnrichment) GetValue() *structpb.Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *AttributeEnrichment) GetProbability() float64 {
+	if x != nil && x.Probability != nil {
+		return *x.Probability
+	}
+	return 0
+}
+
+func (x *AttributeEnrichment) GetCount() int64 {
+	if x != nil && x.Count != nil {
+		return *x.Count
+	}
+	return 0
+}
+
+func (x *AttributeEnrichment) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// PersonFilter selects persons by exact values; empty fields match every
+// person. Name parts match in either script.
+type PersonFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Surname       string                 `protobuf:"bytes,2,opt,name=surname,proto3" json:"surname,omitempty"`
+	Patronymic    string                 `protobuf:"bytes,3,opt,name=patronymic,proto3" json:"patronymic,omitempty"`
+	Age           *int32                 `protobuf:"varint,4,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	Gender        string                 `protobuf:"bytes,5,opt,name=gender,proto3" json:"gender,omitempty"`
+	Nationality   string                 `protobuf:"bytes,6,opt,name=nationality,proto3" json:"nationality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PersonFilter) Reset() {
+	*x = PersonFilter{}
+	mi := &file_persons_v1_persons_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PersonFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PersonFilter) ProtoMessage() {}
+
+func (x *PersonFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PersonFilter.ProtoReflect.Descriptor instead.
+func (*PersonFilter) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PersonFilter) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PersonFilter) GetSurname() string {
+	if x != nil {
+		return x.Surname
+	}
+	return ""
+}
+
+func (x *PersonFilter) GetPatronymic() string {
+	if x != nil {
+		return x.Patronymic
+	}
+	return ""
+}
+
+func (x *PersonFilter) GetAge() int32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+func (x *PersonFilter) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *PersonFilter) GetNationality() string {
+	if x != nil {
+		return x.Nationality
+	}
+	return ""
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *PersonFilter          `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Defaults to 10.
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListRequest) GetFilter() *PersonFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Persons       []*Person              `protobuf:"bytes,1,rep,name=persons,proto3" json:"persons,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResponse) Reset() {
+	*x = ListResponse{}
+	mi := &file_persons_v1_persons_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResponse) ProtoMessage() {}
+
+func (x *ListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResponse.ProtoReflect.Descriptor instead.
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListResponse) GetPersons() []*Person {
+	if x != nil {
+		return x.Persons
+	}
+	return nil
+}
+
+// CreateRequest takes the name as name, surname and patronymic or as a
+// single full_name, split as by POST /persons/parse.
+type CreateRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Name       string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Surname    string                 `protobuf:"bytes,2,opt,name=surname,proto3" json:"surname,omitempty"`
+	Patronymic *string                `protobuf:"bytes,3,opt,name=patronymic,proto3,oneof" json:"patronymic,omitempty"`
+	FullName   string                 `protobuf:"bytes,4,opt,name=full_name,json=fullName,proto3" json:"full_name,omitempty"`
+	// An ISO 3166-1 alpha-2 hint that sharpens the predictions.
+	CountryId     *string `protobuf:"bytes,5,opt,name=country_id,json=countryId,proto3,oneof" json:"country_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateRequest) Reset() {
+	*x = CreateRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRequest) ProtoMessage() {}
+
+func (x *CreateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRequest.ProtoReflect.Descriptor instead.
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetSurname() string {
+	if x != nil {
+		return x.Surname
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetPatronymic() string {
+	if x != nil && x.Patronymic != nil {
+		return *x.Patronymic
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetFullName() string {
+	if x != nil {
+		return x.FullName
+	}
+	return ""
+}
+
+func (x *CreateRequest) GetCountryId() string {
+	if x != nil && x.CountryId != nil {
+		return *x.CountryId
+	}
+	return ""
+}
+
+type UpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Surname       string                 `protobuf:"bytes,3,opt,name=surname,proto3" json:"surname,omitempty"`
+	Patronymic    *string                `protobuf:"bytes,4,opt,name=patronymic,proto3,oneof" json:"patronymic,omitempty"`
+	Age           *int32                 `protobuf:"varint,5,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	Gender        *string                `protobuf:"bytes,6,opt,name=gender,proto3,oneof" json:"gender,omitempty"`
+	Nationality   *string                `protobuf:"bytes,7,opt,name=nationality,proto3,oneof" json:"nationality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetSurname() string {
+	if x != nil {
+		return x.Surname
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetPatronymic() string {
+	if x != nil && x.Patronymic != nil {
+		return *x.Patronymic
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetAge() int32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+func (x *UpdateRequest) GetGender() string {
+	if x != nil && x.Gender != nil {
+		return *x.Gender
+	}
+	return ""
+}
+
+func (x *UpdateRequest) GetNationality() string {
+	if x != nil && x.Nationality != nil {
+		return *x.Nationality
+	}
+	return ""
+}
+
+// PatchRequest changes only the fields that are set. An empty patronymic
+// clears it.
+type PatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	Surname       *string                `protobuf:"bytes,3,opt,name=surname,proto3,oneof" json:"surname,omitempty"`
+	Patronymic    *string                `protobuf:"bytes,4,opt,name=patronymic,proto3,oneof" json:"patronymic,omitempty"`
+	Age           *int32                 `protobuf:"varint,5,opt,name=age,proto3,oneof" json:"age,omitempty"`
+	Gender        *string                `protobuf:"bytes,6,opt,name=gender,proto3,oneof" json:"gender,omitempty"`
+	Nationality   *string                `protobuf:"bytes,7,opt,name=nationality,proto3,oneof" json:"nationality,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchRequest) Reset() {
+	*x = PatchRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchRequest) ProtoMessage() {}
+
+func (x *PatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchRequest.ProtoReflect.Descriptor instead.
+func (*PatchRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PatchRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PatchRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *PatchRequest) GetSurname() string {
+	if x != nil && x.Surname != nil {
+		return *x.Surname
+	}
+	return ""
+}
+
+func (x *PatchRequest) GetPatronymic() string {
+	if x != nil && x.Patronymic != nil {
+		return *x.Patronymic
+	}
+	return ""
+}
+
+func (x *PatchRequest) GetAge() int32 {
+	if x != nil && x.Age != nil {
+		return *x.Age
+	}
+	return 0
+}
+
+func (x *PatchRequest) GetGender() string {
+	if x != nil && x.Gender != nil {
+		return *x.Gender
+	}
+	return ""
+}
+
+func (x *PatchRequest) GetNationality() string {
+	if x != nil && x.Nationality != nil {
+		return *x.Nationality
+	}
+	return ""
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type EnrichRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Fill only the attributes that are still empty.
+	OnlyMissing   bool `protobuf:"varint,2,opt,name=only_missing,json=onlyMissing,proto3" json:"only_missing,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrichRequest) Reset() {
+	*x = EnrichRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrichRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrichRequest) ProtoMessage() {}
+
+func (x *EnrichRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrichRequest.ProtoReflect.Descriptor instead.
+func (*EnrichRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EnrichRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *EnrichRequest) GetOnlyMissing() bool {
+	if x != nil {
+		return x.OnlyMissing
+	}
+	return false
+}
+
+type WatchRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *PersonFilter          `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Resume after this event ID, like Last-Event-ID; without it the stream
+	// starts with the next change.
+	AfterId       *int64 `protobuf:"varint,2,opt,name=after_id,json=afterId,proto3,oneof" json:"after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_persons_v1_persons_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchRequest) GetFilter() *PersonFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *WatchRequest) GetAfterId() int64 {
+	if x != nil && x.AfterId != nil {
+		return *x.AfterId
+	}
+	return 0
+}
+
+type PersonEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// PersonCreated, PersonUpdated, PersonEnriched or PersonDeleted.
+	Type     string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	PersonId int64  `protobuf:"varint,3,opt,name=person_id,json=personId,proto3" json:"person_id,omitempty"`
+	// The state after the change; for PersonDeleted the last state before
+	// the deletion.
+	Person        *Person                `protobuf:"bytes,4,opt,name=person,proto3" json:"person,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PersonEvent) Reset() {
+	*x = PersonEvent{}
+	mi := &file_persons_v1_persons_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PersonEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PersonEvent) ProtoMessage() {}
+
+func (x *PersonEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_persons_v1_persons_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PersonEvent.ProtoReflect.Descriptor instead.
+func (*PersonEvent) Descriptor() ([]byte, []int) {
+	return file_persons_v1_persons_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PersonEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PersonEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PersonEvent) GetPersonId() int64 {
+	if x != nil {
+		return x.PersonId
+	}
+	return 0
+}
+
+func (x *PersonEvent) GetPerson() *Person {
+	if x != nil {
+		return x.Person
+	}
+	return nil
+}
+
+func (x *PersonEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_persons_v1_persons_proto protoreflect.FileDescriptor
+
+const file_persons_v1_persons_proto_rawDesc = "" +
+	"\n" +
+	"\x18persons/v1/persons.proto\x12\n" +
+	"persons.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe4\x03\n" +
+	"\x06Person\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\asurname\x18\x03 \x01(\tR\asurname\x12#\n" +
+	"\n" +
+	"patronymic\x18\x04 \x01(\tH\x00R\n" +
+	"patronymic\x88\x01\x01\x12\x15\n" +
+	"\x03age\x18\x05 \x01(\x05H\x01R\x03age\x88\x01\x01\x12\x1b\n" +
+	"\x06gender\x18\x06 \x01(\tH\x02R\x06gender\x88\x01\x01\x12%\n" +
+	"\vnationality\x18\a \x01(\tH\x03R\vnationality\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"name_latin\x18\b \x01(\tH\x04R\tnameLatin\x88\x01\x01\x12(\n" +
+	"\rsurname_latin\x18\t \x01(\tH\x05R\fsurnameLatin\x88\x01\x01\x12.\n" +
+	"\x10patronymic_latin\x18\n" +
+	" \x01(\tH\x06R\x0fpatronymicLatin\x88\x01\x01\x126\n" +
+	"\n" +
+	"enrichment\x18\v \x01(\v2\x16.persons.v1.EnrichmentR\n" +
+	"enrichmentB\r\n" +
+	"\v_patronymicB\x06\n" +
+	"\x04_ageB\t\n" +
+	"\a_genderB\x0e\n" +
+	"\f_nationalityB\r\n" +
+	"\v_name_latinB\x10\n" +
+	"\x0e_surname_latinB\x13\n" +
+	"\x11_patronymic_latin\"\xbb\x01\n" +
+	"\n" +
+	"Enrichment\x121\n" +
+	"\x03age\x18\x01 \x01(\v2\x1f.persons.v1.AttributeEnrichmentR\x03age\x127\n" +
+	"\x06gender\x18\x02 \x01(\v2\x1f.persons.v1.AttributeEnrichmentR\x06gender\x12A\n" +
+	"\vnationality\x18\x03 \x01(\v2\x1f.persons.v1.AttributeEnrichmentR\vnationality\"\xff\x01\n" +
+	"\x13AttributeEnrichment\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12,\n" +
+	"\x05value\x18\x04 \x01(\v2\x16.google.protobuf.ValueR\x05value\x12%\n" +
+	"\vprobability\x18\x05 \x01(\x01H\x00R\vprobability\x88\x01\x01\x12\x19\n" +
+	"\x05count\x18\x06 \x01(\x03H\x01R\x05count\x88\x01\x01\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reasonB\x0e\n" +
+	"\f_probabilityB\b\n" +
+	"\x06_count\"\xb5\x01\n" +
+	"\fPersonFilter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\asurname\x18\x02 \x01(\tR\asurname\x12\x1e\n" +
+	"\n" +
+	"patronymic\x18\x03 \x01(\tR\n" +
+	"patronymic\x12\x15\n" +
+	"\x03age\x18\x04 \x01(\x05H\x00R\x03age\x88\x01\x01\x12\x16\n" +
+	"\x06gender\x18\x05 \x01(\tR\x06gender\x12 \n" +
+	"\vnationality\x18\x06 \x01(\tR\vnationalityB\x06\n" +
+	"\x04_age\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"m\n" +
+	"\vListRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.persons.v1.PersonFilterR\x06filter\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"<\n" +
+	"\fListResponse\x12,\n" +
+	"\apersons\x18\x01 \x03(\v2\x12.persons.v1.PersonR\apersons\"\xc1\x01\n" +
+	"\rCreateRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\asurname\x18\x02 \x01(\tR\asurname\x12#\n" +
+	"\n" +
+	"patronymic\x18\x03 \x01(\tH\x00R\n" +
+	"patronymic\x88\x01\x01\x12\x1b\n" +
+	"\tfull_name\x18\x04 \x01(\tR\bfullName\x12\"\n" +
+	"\n" +
+	"country_id\x18\x05 \x01(\tH\x01R\tcountryId\x88\x01\x01B\r\n" +
+	"\v_patronymicB\r\n" +
+	"\v_country_id\"\xff\x01\n" +
+	"\rUpdateRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\asurname\x18\x03 \x01(\tR\asurname\x12#\n" +
+	"\n" +
+	"patronymic\x18\x04 \x01(\tH\x00R\n" +
+	"patronymic\x88\x01\x01\x12\x15\n" +
+	"\x03age\x18\x05 \x01(\x05H\x01R\x03age\x88\x01\x01\x12\x1b\n" +
+	"\x06gender\x18\x06 \x01(\tH\x02R\x06gender\x88\x01\x01\x12%\n" +
+	"\vnationality\x18\a \x01(\tH\x03R\vnationality\x88\x01\x01B\r\n" +
+	"\v_patronymicB\x06\n" +
+	"\x04_ageB\t\n" +
+	"\a_genderB\x0e\n" +
+	"\f_nationality\"\x9d\x02\n" +
+	"\fPatchRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x1d\n" +
+	"\asurname\x18\x03 \x01(\tH\x01R\asurname\x88\x01\x01\x12#\n" +
+	"\n" +
+	"patronymic\x18\x04 \x01(\tH\x02R\n" +
+	"patronymic\x88\x01\x01\x12\x15\n" +
+	"\x03age\x18\x05 \x01(\x05H\x03R\x03age\x88\x01\x01\x12\x1b\n" +
+	"\x06gender\x18\x06 \x01(\tH\x04R\x06gender\x88\x01\x01\x12%\n" +
+	"\vnationality\x18\a \x01(\tH\x05R\vnationality\x88\x01\x01B\a\n" +
+	"\x05_nameB\n" +
+	"\n" +
+	"\b_surnameB\r\n" +
+	"\v_patronymicB\x06\n" +
+	"\x04_ageB\t\n" +
+	"\a_genderB\x0e\n" +
+	"\f_nationality\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"B\n" +
+	"\rEnrichRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12!\n" +
+	"\fonly_missing\x18\x02 \x01(\bR\vonlyMissing\"m\n" +
+	"\fWatchRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.persons.v1.PersonFilterR\x06filter\x12\x1e\n" +
+	"\bafter_id\x18\x02 \x01(\x03H\x00R\aafterId\x88\x01\x01B\v\n" +
+	"\t_after_id\"\xb7\x01\n" +
+	"\vPersonEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1b\n" +
+	"\tperson_id\x18\x03 \x01(\x03R\bpersonId\x12*\n" +
+	"\x06person\x18\x04 \x01(\v2\x12.persons.v1.PersonR\x06person\x12;\n" +
+	"\voccurred_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt2\xd6\x03\n" +
+	"\rPersonService\x121\n" +
+	"\x03Get\x12\x16.persons.v1.GetRequest\x1a\x12.persons.v1.Person\x129\n" +
+	"\x04List\x12\x17.persons.v1.ListRequest\x1a\x18.persons.v1.ListResponse\x127\n" +
+	"\x06Create\x12\x19.persons.v1.CreateRequest\x1a\x12.persons.v1.Person\x127\n" +
+	"\x06Update\x12\x19.persons.v1.UpdateRequest\x1a\x12.persons.v1.Person\x125\n" +
+	"\x05Patch\x12\x18.persons.v1.PatchRequest\x1a\x12.persons.v1.Person\x127\n" +
+	"\x06Delete\x12\x19.persons.v1.DeleteRequest\x1a\x12.persons.v1.Person\x127\n" +
+	"\x06Enrich\x12\x19.persons.v1.EnrichRequest\x1a\x12.persons.v1.Person\x12<\n" +
+	"\x05Watch\x12\x18.persons.v1.WatchRequest\x1a\x17.persons.v1.PersonEvent0\x01BPZNgithub.com/Krchnk/EffectiveMobileFullNameTest/internal/api/personsv1;personsv1b\x06proto3"
+
+var (
+	file_persons_v1_persons_proto_rawDescOnce sync.Once
+	file_persons_v1_persons_proto_rawDescData []byte
+)
+
+func file_persons_v1_persons_proto_rawDescGZIP() []byte {
+	file_persons_v1_persons_proto_rawDescOnce.Do(func() {
+		file_persons_v1_persons_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_persons_v1_persons_proto_rawDesc), len(file_persons_v1_persons_proto_rawDesc)))
+	})
+	return file_persons_v1_persons_proto_rawDescData
+}
+
+var file_persons_v1_persons_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_persons_v1_persons_proto_goTypes = []any{
+	(*Person)(nil),                // 0: persons.v1.Person
+	(*Enrichment)(nil),            // 1: persons.v1.Enrichment
+	(*AttributeEnrichment)(nil),   // 2: persons.v1.AttributeEnrichment
+	(*PersonFilter)(nil),          // 3: persons.v1.PersonFilter
+	(*GetRequest)(nil),            // 4: persons.v1.GetRequest
+	(*ListRequest)(nil),           // 5: persons.v1.ListRequest
+	(*ListResponse)(nil),          // 6: persons.v1.ListResponse
+	(*CreateRequest)(nil),         // 7: persons.v1.CreateRequest
+	(*UpdateRequest)(nil),         // 8: persons.v1.UpdateRequest
+	(*PatchRequest)(nil),          // 9: persons.v1.PatchRequest
+	(*DeleteRequest)(nil),         // 10: persons.v1.DeleteRequest
+	(*EnrichRequest)(nil),         // 11: persons.v1.EnrichRequest
+	(*WatchRequest)(nil),          // 12: persons.v1.WatchRequest
+	(*PersonEvent)(nil),           // 13: persons.v1.PersonEvent
+	(*structpb.Value)(nil),        // 14: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil), // 15: google.protobuf.Timestamp
+}
+var file_persons_v1_persons_proto_depIdxs = []int32{
+	1,  // 0: persons.v1.Person.enrichment:type_name -> persons.v1.Enrichment
+	2,  // 1: persons.v1.Enrichment.age:type_name -> persons.v1.AttributeEnrichment
+	2,  // 2: persons.v1.Enrichment.gender:type_name -> persons.v1.AttributeEnrichment
+	2,  // 3: persons.v1.Enrichment.nationality:type_name -> persons.v1.AttributeEnrichment
+	14, // 4: persons.v1.AttributeEnrichment.value:type_name -> google.protobuf.Value
+	3,  // 5: persons.v1.ListRequest.filter:type_name -> persons.v1.PersonFilter
+	0,  // 6: persons.v1.ListResponse.persons:type_name -> persons.v1.Person
+	3,  // 7: persons.v1.WatchRequest.filter:type_name -> persons.v1.PersonFilter
+	0,  // 8: persons.v1.PersonEvent.person:type_name -> persons.v1.Person
+	15, // 9: persons.v1.PersonEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	4,  // 10: persons.v1.PersonService.Get:input_type -> persons.v1.GetRequest
+	5,  // 11: persons.v1.PersonService.List:input_type -> persons.v1.ListRequest
+	7,  // 12: persons.v1.PersonService.Create:input_type -> persons.v1.CreateRequest
+	8,  // 13: persons.v1.PersonService.Update:input_type -> persons.v1.UpdateRequest
+	9,  // 14: persons.v1.PersonService.Patch:input_type -> persons.v1.PatchRequest
+	10, // 15: persons.v1.PersonService.Delete:input_type -> persons.v1.DeleteRequest
+	11, // 16: persons.v1.PersonService.Enrich:input_type -> persons.v1.EnrichRequest
+	12, // 17: persons.v1.PersonService.Watch:input_type -> persons.v1.WatchRequest
+	0,  // 18: persons.v1.PersonService.Get:output_type -> persons.v1.Person
+	6,  // 19: persons.v1.PersonService.List:output_type -> persons.v1.ListResponse
+	0,  // 20: persons.v1.PersonService.Create:output_type -> persons.v1.Person
+	0,  // 21: persons.v1.PersonService.Update:output_type -> persons.v1.Person
+	0,  // 22: persons.v1.PersonService.Patch:output_type -> persons.v1.Person
+	0,  // 23: persons.v1.PersonService.Delete:output_type -> persons.v1.Person
+	0,  // 24: persons.v1.PersonService.Enrich:output_type -> persons.v1.Person
+	13, // 25: persons.v1.PersonService.Watch:output_type -> persons.v1.PersonEvent
+	18, // [18:26] is the sub-list for method output_type
+	10, // [10:18] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_persons_v1_persons_proto_init() }
+func file_persons_v1_persons_proto_init() {
+	if File_persons_v1_persons_proto != nil {
+		return
+	}
+	file_persons_v1_persons_proto_msgTypes[0].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[2].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[3].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[7].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[8].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[9].OneofWrappers = []any{}
+	file_persons_v1_persons_proto_msgTypes[12].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_persons_v1_persons_proto_rawDesc), len(file_persons_v1_persons_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_persons_v1_persons_proto_goTypes,
+		DependencyIndexes: file_persons_v1_persons_proto_depIdxs,
+		MessageInfos:      file_persons_v1_persons_proto_msgTypes,
+	}.Build()
+	File_persons_v1_persons_proto = out.File
+	file_persons_v1_persons_proto_goTypes = nil
+	file_persons_v1_persons_proto_depIdxs = nil
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/auth"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Rate limit classes, each with its own bucket per client.
+const (
+	limitRead   = "read"
+	limitWrite  = "write"
+	limitEnrich = "enrich"
+)
+
+type rateLimiter struct {
+	limiter ratelimit.Limiter
+	limits  map[string]ratelimit.Limit
+}
+
+// newRateLimiter returns nil when rate limiting is disabled; limit on a nil
+// rateLimiter lets every request through.
+func newRateLimiter(cfg config.RateLimitConfig, db *sql.DB) *rateLimiter {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	toLimit := func(l config.LimitConfig) ratelimit.Limit {
+		return ratelimit.PerPeriod(l.Requests, l.Period, l.Burst)
+	}
+	rl := &rateLimiter{limits: map[string]ratelimit.Limit{
+		limitRead:   toLimit(cfg.Read),
+		limitWrite:  toLimit(cfg.Write),
+		limitEnrich: toLimit(cfg.Enrich),
+	}}
+
+	switch cfg.Backend {
+	case "postgres":
+		// Keep buckets at least as long as the slowest one takes to refill.
+		retention := time.Hour
+		for _, l := range []config.LimitConfig{cfg.Read, cfg.Write, cfg.Enrich} {
+			retention = max(retention, 2*l.Period)
+		}
+		rl.limiter = ratelimit.NewPostgresLimiter(db, retention)
+	default:
+		rl.limiter = ratelimit.NewMemoryLimiter()
+	}
+	logrus.WithField("backend", cfg.Backend).Info("Rate limiting enabled")
+	return rl
+}
+
+func (rl *rateLimiter) limit(class string) gin.HandlerFunc {
+	if rl == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		res, ok := rl.allow(c, class)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.limits[class].Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			retryAfter := int(math.Max(1, math.Ceil(res.RetryAfter.Seconds())))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow takes one request of class from the caller's bucket. It reports
+// false when the limiter failed and the request should go through
+// unlimited.
+func (rl *rateLimiter) allow(c *gin.Context, class string) (ratelimit.Result, bool) {
+	return rl.allowClient(c.Request.Context(), clientKey(c), class)
+}
+
+// allowClient is allow for a caller identified by client.
+func (rl *rateLimiter) allowClient(ctx context.Context, client, class string) (ratelimit.Result, bool) {
+	res, err := rl.limiter.Allow(ctx, class+":"+client, rl.limits[class])
+	if err != nil {
+		// A broken limiter backend must not take the API down with it.
+		logrus.WithError(err).Error("Rate limiter failed, letting request through")
+		return ratelimit.Result{}, false
+	}
+	if !res.Allowed {
+		logrus.WithFields(logrus.Fields{
+			"client":      client,
+			"class":       class,
+			"retry_after": int(math.Max(1, math.Ceil(res.RetryAfter.Seconds()))),
+		}).Warn("Rate limit exceeded")
+	}
+	return res, true
+}
+
+// charge is allow for requests that cost more than the route they arrive
+// on, such as GraphQL mutations. It reports whether the request may go on.
+func (rl *rateLimiter) charge(c *gin.Context, class string) bool {
+	if rl == nil {
+		return true
+	}
+	res, ok := rl.allow(c, class)
+	return !ok || res.Allowed
+}
+
+// clientKey identifies the caller by principal when authenticated and by
+// IP address otherwise.
+func clientKey(c *gin.Context) string {
+	if p := auth.FromContext(c.Request.Context()); p != nil {
+		return p.Method + ":" + p.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
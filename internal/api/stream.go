@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// StreamPersons godoc
+// @Summary Stream person changes
+// @Description Pushes PersonCreated, PersonUpdated, PersonEnriched and PersonDeleted events as Server-Sent Events. The SSE event name is the event type, the id is the outbox event ID and the data is a models.PersonEvent. The GetPersons filters select events by the person they carry. A client reconnecting with Last-Event-ID (or last_event_id) receives the events it missed while they are still buffered or kept by the outbox retention; without it the stream starts with the next change. Idle streams get a comment line every heartbeat interval.
+// @Tags persons
+// @Produce text/event-stream
+// @Param name query string false "Filter by name"
+// @Param surname query string false "Filter by surname"
+// @Param patronymic query string false "Filter by patronymic"
+// @Param age query int false "Filter by age"
+// @Param gender query string false "Filter by gender" Enums(male, female, other)
+// @Param nationality query string false "Filter by nationality"
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Param last_event_id query int false "Same as the Last-Event-ID header, for clients that cannot set it"
+// @Success 200 {object} models.PersonEvent "Event stream"
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Event stream disabled"
+// @Failure 429 {object} models.ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} models.ErrorResponse "Too many stream clients"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /persons/stream [get]
+func (h *Handler) StreamPersons(c *gin.Context) {
+	logrus.Info("Received GET /persons/stream request")
+	if h.feed == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event stream disabled"})
+		return
+	}
+	filter, ok := personFilter(c)
+	if !ok {
+		return
+	}
+
+	cursor := h.feed.Last()
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" {
+		id, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil || id < 0 {
+			logrus.WithField("last_event_id", lastID).Error("Invalid Last-Event-ID")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		cursor = id
+	}
+
+	if !h.feed.Join() {
+		logrus.Warn("Rejected stream client, limit reached")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many stream clients"})
+		return
+	}
+	defer h.feed.Leave()
+
+	// The server write timeout would cut the stream off.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logrus.WithError(err).Warn("Failed to clear the write deadline of the stream")
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	logrus.WithFields(logrus.Fields{
+		"from":      cursor,
+		"principal": principalName(c),
+	}).Info("Stream client connected")
+
+	ctx := c.Request.Context()
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		changed := h.feed.Changed()
+		events, next, err := h.feed.Since(ctx, cursor)
+		if err != nil {
+			if ctx.Err() == nil {
+				logrus.WithError(err).Error("Failed to read events for the stream")
+			}
+			return
+		}
+		cursor = next
+		for _, e := range events {
+			if !filter.Matches(e.Person) {
+				continue
+			}
+			c.Render(-1, sse.Event{Id: strconv.FormatInt(e.ID, 10), Event: e.Type, Data: e})
+		}
+		if len(events) > 0 {
+			c.Writer.Flush()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.feed.Done():
+			return
+		case <-changed:
+		case <-heartbeat.C:
+			if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxDeliveriesLimit bounds one page of the delivery log.
+const maxDeliveriesLimit = 500
+
+// CreateWebhook godoc
+// @Summary Subscribe a webhook
+// @Description Registers a URL that receives the listed person events as signed POST requests. The secret is generated when omitted and is returned only in this response. Deliveries carry X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>">.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param subscription body models.WebhookSubscriptionRequest true "Subscription"
+// @Success 201 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	sub, err := h.subscriptions.Create(c.Request.Context(), req)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create webhook subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook subscription"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"id":        sub.ID,
+		"url":       sub.URL,
+		"principal": principalName(c),
+	}).Info("Webhook subscription created")
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListWebhooks godoc
+// @Summary List webhook subscriptions
+// @Description Returns every subscription, including disabled ones and why they were disabled. Secrets are not returned.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	if !h.webhooksEnabled(c) {
+		return
+	}
+	subs, err := h.subscriptions.List(c.Request.Context())
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list webhook subscriptions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhook subscriptions"})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook subscription
+// @Tags admin
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Subscription not found or webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+	sub, err := h.subscriptions.Get(c.Request.Context(), id)
+	if !subscriptionFound(c, id, err) {
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// UpdateWebhook godoc
+// @Summary Replace a webhook subscription
+// @Description Replaces the URL and event types. The secret is kept unless a new one is sent. Sending active re-enables a disabled subscription and resets its failure count; its pending deliveries are then sent.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param subscription body models.WebhookSubscriptionRequest true "Subscription"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Subscription not found or webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logrus.WithError(err).Error("Invalid request body")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	sub, err := h.subscriptions.Update(c.Request.Context(), id, req)
+	if !subscriptionFound(c, id, err) {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"active":    sub.Active,
+		"principal": principalName(c),
+	}).Info("Webhook subscription updated")
+	c.JSON(http.StatusOK, sub)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook subscription
+// @Description Deletes the subscription together with its delivery log
+// @Tags admin
+// @Param id path int true "Subscription ID"
+// @Success 204 "Subscription deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Subscription not found or webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+	if !subscriptionFound(c, id, h.subscriptions.Delete(c.Request.Context(), id)) {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"id":        id,
+		"principal": principalName(c),
+	}).Info("Webhook subscription deleted")
+	c.Status(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Returns the delivery log of a subscription, newest first: attempts, last response status or error, and when the next attempt is due
+// @Tags admin
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param status query string false "Filter by status" Enums(pending, succeeded, failed)
+// @Param limit query int false "Number of items to return" default(50)
+// @Param offset query int false "Number of items to skip" default(0)
+// @Success 200 {array} models.WebhookDelivery
+// @Failure 400 {object} models.ErrorResponse "Invalid parameters"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ForbiddenResponse "Missing permission"
+// @Failure 404 {object} models.ErrorResponse "Subscription not found or webhooks disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	id, ok := h.webhookID(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status parameter"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.subscriptions.Get(ctx, id); !subscriptionFound(c, id, err) {
+		return
+	}
+	deliveries, err := h.subscriptions.Deliveries(ctx, id, status, limit, offset)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list webhook deliveries")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhook deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *Handler) webhooksEnabled(c *gin.Context) bool {
+	if h.subscriptions == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhooks disabled"})
+		return false
+	}
+	return true
+}
+
+// webhookID parses the subscription ID and checks that webhooks are
+// enabled, writing the error response otherwise.
+func (h *Handler) webhookID(c *gin.Context) (int, bool) {
+	if !h.webhooksEnabled(c) {
+		return 0, false
+	}
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		logrus.WithField("id", idStr).Error("Invalid ID parameter")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// subscriptionFound writes the error response for err and reports whether
+// there was none.
+func subscriptionFound(c *gin.Context, id int, err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		logrus.WithField("id", id).Warn("Webhook subscription not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return false
+	case err != nil:
+		logrus.WithError(err).WithField("id", id).Error("Webhook subscription query failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to access webhook subscription"})
+		return false
+	}
+	return true
+}
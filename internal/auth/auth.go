@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoCredentials means the request carries no credentials this
+	// authenticator understands, so the next one may be tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means credentials were present but rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is the authenticated caller of a request. Roles come from the
+// API key configuration or the JWT roles claim; Permissions are resolved from
+// them by RBAC.
+type Principal struct {
+	Subject     string       `json:"subject"`
+	Method      string       `json:"method"`
+	Roles       []string     `json:"roles,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by WithPrincipal, or nil for
+// unauthenticated requests.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// HashAPIKey returns the form in which API keys are kept in configuration.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
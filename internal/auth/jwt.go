@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type JWTOptions struct {
+	// HMACSecret verifies HS256/HS384/HS512 tokens.
+	HMACSecret string
+	// JWKSFile holds public keys that verify RS*, PS* and ES* tokens.
+	JWKSFile string
+	Issuer   string
+	Audience string
+	// RolesClaim names the claim holding the caller's roles, either as a
+	// list or as a space-separated string like the OAuth "scope" claim.
+	RolesClaim string
+}
+
+type JWTAuthenticator struct {
+	hmacSecret []byte
+	keys       *JWKS
+	rolesClaim string
+	parser     *jwt.Parser
+}
+
+func NewJWTAuthenticator(opts JWTOptions) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{rolesClaim: opts.RolesClaim}
+	var methods []string
+
+	if opts.HMACSecret != "" {
+		a.hmacSecret = []byte(opts.HMACSecret)
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if opts.JWKSFile != "" {
+		keys, err := LoadJWKS(opts.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512")
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("jwt: either an HMAC secret or a JWKS file is required")
+	}
+
+	parserOpts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+	a.parser = jwt.NewParser(parserOpts...)
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(token), claims, a.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	return &Principal{Subject: subject, Method: MethodJWT, Roles: a.roles(claims)}, nil
+}
+
+func (a *JWTAuthenticator) roles(claims jwt.MapClaims) []string {
+	switch v := claims[a.rolesClaim].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, item := range v {
+			if role, ok := item.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles
+	default:
+		return nil
+	}
+}
+
+func (a *JWTAuthenticator) key(token *jwt.Token) (interface{}, error) {
+	if strings.HasPrefix(token.Method.Alg(), "HS") {
+		if a.hmacSecret == nil {
+			return nil, errors.New("HMAC tokens are not accepted")
+		}
+		return a.hmacSecret, nil
+	}
+
+	if a.keys == nil {
+		return nil, errors.New("asymmetric tokens are not accepted")
+	}
+	kid, _ := token.Header["kid"].(string)
+	return a.keys.Key(kid)
+}
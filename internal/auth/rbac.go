@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Permission string
+
+const (
+	PermPersonsRead   Permission = "persons:read"
+	PermPersonsWrite  Permission = "persons:write"
+	PermPersonsDelete Permission = "persons:delete"
+	PermMetricsRead   Permission = "metrics:read"
+	// PermAdmin grants every other permission.
+	PermAdmin Permission = "admin"
+)
+
+var knownPermissions = []Permission{PermPersonsRead, PermPersonsWrite, PermPersonsDelete, PermMetricsRead, PermAdmin}
+
+func ParsePermission(s string) (Permission, error) {
+	p := Permission(s)
+	if !slices.Contains(knownPermissions, p) {
+		return "", fmt.Errorf("unknown permission %q", s)
+	}
+	return p, nil
+}
+
+// RBAC maps role names to the permissions they grant.
+type RBAC struct {
+	roles map[string][]Permission
+}
+
+func NewRBAC(roles map[string][]Permission) *RBAC {
+	return &RBAC{roles: roles}
+}
+
+// Permissions resolves roles to permissions. A role that is not defined but
+// names a permission itself (e.g. an OAuth scope "persons:read") grants that
+// permission; unknown roles grant nothing.
+func (r *RBAC) Permissions(roles []string) []Permission {
+	var perms []Permission
+	for _, role := range roles {
+		granted, ok := r.roles[role]
+		if !ok {
+			if p, err := ParsePermission(role); err == nil {
+				granted = []Permission{p}
+			}
+		}
+		for _, p := range granted {
+			if !slices.Contains(perms, p) {
+				perms = append(perms, p)
+			}
+		}
+	}
+	return perms
+}
+
+func (p *Principal) Can(perm Permission) bool {
+	return slices.Contains(p.Permissions, PermAdmin) || slices.Contains(p.Permissions, perm)
+}
@@ -0,0 +1,971 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the service. Values are resolved in order:
+// built-in defaults, the optional YAML/TOML file, then environment variables
+// (including those loaded from .env).
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Enrichment EnrichmentConfig `yaml:"enrichment"`
+	Health     HealthConfig     `yaml:"health"`
+	Auth       AuthConfig       `yaml:"auth"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Outbox     OutboxConfig     `yaml:"outbox"`
+	Webhooks   WebhooksConfig   `yaml:"webhooks"`
+	Stream     StreamConfig     `yaml:"stream"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig describes the Postgres connection. When URL is set it is
+// used as is and the individual connection fields are ignored.
+type DatabaseConfig struct {
+	URL         string `yaml:"url"`
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port"`
+	User        string `yaml:"user"`
+	Password    string `yaml:"password"`
+	Name        string `yaml:"name"`
+	SSLMode     string `yaml:"sslmode"`
+	SSLRootCert string `yaml:"sslrootcert"`
+	SSLCert     string `yaml:"sslcert"`
+	SSLKey      string `yaml:"sslkey"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	ConnectRetries    int           `yaml:"connect_retries"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff"`
+
+	// MigrationsSource is "embed" for the migrations compiled into the
+	// binary or a golang-migrate source URL such as file://migrations.
+	MigrationsSource string `yaml:"migrations_source"`
+	AutoMigrate      bool   `yaml:"auto_migrate"`
+}
+
+type EnrichmentConfig struct {
+	AgeAPIURL         string `yaml:"age_api_url"`
+	GenderAPIURL      string `yaml:"gender_api_url"`
+	NationalityAPIURL string `yaml:"nationality_api_url"`
+	// QuotaReserve stops calls to a provider once its remaining quota drops
+	// to this many requests, until the quota window resets.
+	QuotaReserve int `yaml:"quota_reserve"`
+	// QuotaResetInterval is how long a provider stays throttled when it
+	// reported a low quota or answered 429 without saying when it resets.
+	QuotaResetInterval time.Duration `yaml:"quota_reset_interval"`
+	// CountryHints looks up nationality first and passes the top country to
+	// agify and genderize as country_id.
+	CountryHints bool `yaml:"country_hints"`
+	// GenderRules infers gender from Russian patronymic and surname endings
+	// and asks the gender chain only when the rules give no answer.
+	GenderRules bool `yaml:"gender_rules"`
+	// NameScript is "original" to send first names to the providers as
+	// stored or "latin" to send their transliteration.
+	NameScript string `yaml:"name_script"`
+	// APIKey is sent as the apikey parameter accepted by the paid plans of
+	// agify, genderize and nationalize.
+	APIKey string `yaml:"api_key"`
+
+	HTTPTimeout time.Duration `yaml:"http_timeout"`
+	UserAgent   string        `yaml:"user_agent"`
+	// ProxyURL overrides the HTTP_PROXY/HTTPS_PROXY environment variables.
+	ProxyURL              string `yaml:"proxy_url"`
+	CACertFile            string `yaml:"ca_cert_file"`
+	TLSInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify"`
+
+	RetryAttempts   int           `yaml:"retry_attempts"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`
+	RetryMaxBackoff time.Duration `yaml:"retry_max_backoff"`
+	// BreakerThreshold consecutive failures open a provider's circuit for
+	// BreakerOpenTimeout, after which a single probe request is let through.
+	BreakerThreshold   int           `yaml:"breaker_threshold"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout"`
+
+	Thresholds ThresholdsConfig `yaml:"thresholds"`
+	// LowConfidence is "null" to drop predictions below the thresholds or
+	// "mark" to keep them flagged as low_confidence. Either way the reason
+	// is recorded in the person's enrichment details.
+	LowConfidence string `yaml:"low_confidence"`
+
+	Offline OfflineConfig `yaml:"offline"`
+	// Chains lists per attribute the sources asked in turn until one has a
+	// prediction. An empty chain follows offline.mode: the API, then the
+	// cache, with the offline dataset before or after them.
+	Chains ChainsConfig `yaml:"chains"`
+	Cache  CacheConfig  `yaml:"cache"`
+}
+
+// Chain link sources.
+const (
+	SourceAPI     = "api"
+	SourceCache   = "cache"
+	SourceOffline = "offline"
+)
+
+type ChainsConfig struct {
+	Age         []ChainLinkConfig `yaml:"age"`
+	Gender      []ChainLinkConfig `yaml:"gender"`
+	Nationality []ChainLinkConfig `yaml:"nationality"`
+}
+
+// ChainLinkConfig is one link of a chain: "api", "cache" or "offline". A
+// positive Timeout bounds the time spent on this link before the next one
+// is asked.
+type ChainLinkConfig struct {
+	Source  string        `yaml:"source"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CacheConfig keeps API predictions in memory for TTL, at most MaxEntries
+// per attribute, so the cache link can answer while an API is down.
+type CacheConfig struct {
+	TTL        time.Duration `yaml:"ttl"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+// ResolvedChain returns links, or the chain offline.mode implies when
+// links is empty.
+func (c EnrichmentConfig) ResolvedChain(links []ChainLinkConfig) []ChainLinkConfig {
+	if len(links) > 0 {
+		return links
+	}
+	api := []ChainLinkConfig{{Source: SourceAPI}, {Source: SourceCache}}
+	switch c.Offline.Mode {
+	case "primary":
+		return append([]ChainLinkConfig{{Source: SourceOffline}}, api...)
+	case "fallback":
+		return append(api, ChainLinkConfig{Source: SourceOffline})
+	default:
+		return api
+	}
+}
+
+// OfflineConfig answers lookups from a local dataset for environments
+// without internet access.
+type OfflineConfig struct {
+	// Mode is "off", "primary" to ask the dataset before the APIs, or
+	// "fallback" to ask it only for names the APIs could not answer.
+	Mode string `yaml:"mode"`
+	// File is a CSV or JSON dataset, told apart by the extension.
+	File string `yaml:"file"`
+}
+
+// ThresholdsConfig sets the minimum confidence a prediction needs per
+// attribute. agify reports no probability, so only its sample count can be
+// checked.
+type ThresholdsConfig struct {
+	Age         ThresholdConfig `yaml:"age"`
+	Gender      ThresholdConfig `yaml:"gender"`
+	Nationality ThresholdConfig `yaml:"nationality"`
+}
+
+// ThresholdConfig is met when a prediction's probability is at least
+// MinProbability and it is based on at least MinCount samples.
+type ThresholdConfig struct {
+	MinProbability float64 `yaml:"min_probability"`
+	MinCount       int     `yaml:"min_count"`
+}
+
+type HealthConfig struct {
+	CheckProviders bool `yaml:"check_providers"`
+	// ProvidersCacheTTL is how long a provider check is reused, so frequent
+	// probes do not spend the providers' quota.
+	ProvidersCacheTTL time.Duration `yaml:"providers_cache_ttl"`
+}
+
+type AuthConfig struct {
+	Enabled      bool           `yaml:"enabled"`
+	APIKeyHeader string         `yaml:"api_key_header"`
+	APIKeys      []APIKeyConfig `yaml:"api_keys"`
+	JWT          JWTConfig      `yaml:"jwt"`
+	// Roles maps a role name to the permissions it grants: persons:read,
+	// persons:write, persons:delete, metrics:read or admin.
+	Roles map[string][]string `yaml:"roles"`
+}
+
+// APIKeyConfig stores a key as "sha256:<hex>" (see the hash-api-key command),
+// never in plaintext.
+type APIKeyConfig struct {
+	Name  string   `yaml:"name"`
+	Hash  string   `yaml:"hash"`
+	Roles []string `yaml:"roles"`
+}
+
+type JWTConfig struct {
+	HMACSecret string `yaml:"hmac_secret"`
+	JWKSFile   string `yaml:"jwks_file"`
+	Issuer     string `yaml:"issuer"`
+	Audience   string `yaml:"audience"`
+	RolesClaim string `yaml:"roles_claim"`
+}
+
+// RateLimitConfig limits clients per API key (or per IP for anonymous
+// requests) with separate buckets for reads, writes and requests that
+// trigger enrichment.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend is "memory" (per instance) or "postgres" (shared by replicas).
+	Backend string      `yaml:"backend"`
+	Read    LimitConfig `yaml:"read"`
+	Write   LimitConfig `yaml:"write"`
+	Enrich  LimitConfig `yaml:"enrich"`
+}
+
+// LimitConfig allows Requests per Period, at most Burst at once. A long
+// period such as 24h turns the limit into a daily quota.
+type LimitConfig struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+// Outbox sinks. SinkNone drops the events, for deployments where only
+// webhook subscriptions or the event stream read them.
+const (
+	SinkNone    = "none"
+	SinkStdout  = "stdout"
+	SinkWebhook = "webhook"
+	SinkNATS    = "nats"
+)
+
+// OutboxConfig records person events in the outbox table in the same
+// transaction as each change. A relay polls the table and publishes the
+// events to Sink; an event is marked published only after the sink
+// accepted it, so delivery is at least once.
+type OutboxConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Sink is "none", "stdout", "webhook" or "nats".
+	Sink         string        `yaml:"sink"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	// A failed publish is retried after RetryBackoff, doubling up to
+	// RetryMaxBackoff.
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`
+	RetryMaxBackoff time.Duration `yaml:"retry_max_backoff"`
+	// Retention is how long published events stay in the table.
+	Retention time.Duration `yaml:"retention"`
+
+	Webhook OutboxWebhookConfig `yaml:"webhook"`
+	NATS    OutboxNATSConfig    `yaml:"nats"`
+}
+
+// OutboxWebhookConfig POSTs each event as JSON to URL; any 2xx response
+// acknowledges it.
+type OutboxWebhookConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// OutboxNATSConfig publishes each event to JetStream on the subject
+// <SubjectPrefix>.<event type>, e.g. persons.PersonCreated. A stream must
+// capture those subjects; the event ID is the message ID, so JetStream
+// drops duplicates within its deduplication window.
+type OutboxNATSConfig struct {
+	URL           string `yaml:"url"`
+	SubjectPrefix string `yaml:"subject_prefix"`
+}
+
+// WebhooksConfig runs the delivery worker for webhook subscriptions. The
+// events come from the outbox, which must be enabled.
+type WebhooksConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	// Concurrency is the number of deliveries sent at once.
+	Concurrency int           `yaml:"concurrency"`
+	Timeout     time.Duration `yaml:"timeout"`
+	// A failed delivery is retried after RetryBackoff, doubling up to
+	// RetryMaxBackoff, and given up after MaxAttempts attempts.
+	MaxAttempts     int           `yaml:"max_attempts"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`
+	RetryMaxBackoff time.Duration `yaml:"retry_max_backoff"`
+	// DisableAfter consecutive failed attempts disable a subscription.
+	DisableAfter int `yaml:"disable_after"`
+	// Retention is how long finished deliveries stay in the delivery log.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// StreamConfig serves GET /persons/stream from the outbox table, which must
+// be enabled. The latest BufferSize events are kept in memory for clients
+// resuming with Last-Event-ID; older ones are read back from the table
+// while the outbox retention keeps them.
+type StreamConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BufferSize   int           `yaml:"buffer_size"`
+	// Heartbeat is how often an idle stream gets a comment line, so
+	// proxies do not close it.
+	Heartbeat  time.Duration `yaml:"heartbeat"`
+	MaxClients int           `yaml:"max_clients"`
+}
+
+// GRPCConfig serves the persons.v1.PersonService gRPC API on its own
+// address, with the same authentication and permissions as the REST API.
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`
+	// Reflection lets tools such as grpcurl list the services.
+	Reflection bool `yaml:"reflection"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "secret",
+			Name:     "persons_db",
+			SSLMode:  "disable",
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+
+			ConnectRetries:    10,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 10 * time.Second,
+
+			MigrationsSource: "embed",
+			AutoMigrate:      true,
+		},
+		Enrichment: EnrichmentConfig{
+			AgeAPIURL:          "https://api.agify.io",
+			GenderAPIURL:       "https://api.genderize.io",
+			NationalityAPIURL:  "https://api.nationalize.io",
+			QuotaReserve:       5,
+			QuotaResetInterval: 10 * time.Minute,
+			NameScript:         "original",
+			HTTPTimeout:        10 * time.Second,
+			UserAgent:          "EffectiveMobileFullNameTest/1.0",
+
+			RetryAttempts:   3,
+			RetryBackoff:    200 * time.Millisecond,
+			RetryMaxBackoff: 2 * time.Second,
+
+			BreakerThreshold:   5,
+			BreakerOpenTimeout: 30 * time.Second,
+
+			LowConfidence: "null",
+			Offline:       OfflineConfig{Mode: "off"},
+			Cache:         CacheConfig{TTL: 24 * time.Hour, MaxEntries: 10000},
+		},
+		Health: HealthConfig{ProvidersCacheTTL: 5 * time.Minute},
+		Auth: AuthConfig{
+			APIKeyHeader: "X-API-Key",
+			JWT:          JWTConfig{RolesClaim: "roles"},
+			Roles: map[string][]string{
+				"reader":     {"persons:read"},
+				"writer":     {"persons:read", "persons:write"},
+				"monitoring": {"metrics:read"},
+				"admin":      {"admin"},
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+			Read:    LimitConfig{Requests: 600, Period: time.Minute, Burst: 100},
+			Write:   LimitConfig{Requests: 120, Period: time.Minute, Burst: 20},
+			Enrich:  LimitConfig{Requests: 30, Period: time.Minute, Burst: 10},
+		},
+		Outbox: OutboxConfig{
+			Sink:            SinkStdout,
+			PollInterval:    time.Second,
+			BatchSize:       100,
+			RetryBackoff:    time.Second,
+			RetryMaxBackoff: time.Minute,
+			Retention:       7 * 24 * time.Hour,
+			Webhook:         OutboxWebhookConfig{Timeout: 10 * time.Second},
+			NATS:            OutboxNATSConfig{URL: "nats://localhost:4222", SubjectPrefix: "persons"},
+		},
+		Webhooks: WebhooksConfig{
+			PollInterval:    time.Second,
+			BatchSize:       50,
+			Concurrency:     4,
+			Timeout:         10 * time.Second,
+			MaxAttempts:     8,
+			RetryBackoff:    10 * time.Second,
+			RetryMaxBackoff: time.Hour,
+			DisableAfter:    20,
+			Retention:       30 * 24 * time.Hour,
+		},
+		Stream: StreamConfig{
+			PollInterval: 500 * time.Millisecond,
+			BufferSize:   1000,
+			Heartbeat:    15 * time.Second,
+			MaxClients:   100,
+		},
+		GRPC: GRPCConfig{
+			Addr:       ":9090",
+			Reflection: true,
+		},
+	}
+}
+
+// Load builds the configuration. path points to an optional YAML or TOML
+// file; when empty, CONFIG_FILE is consulted.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, fmt.Errorf("load config file %s: %w", path, err)
+		}
+	}
+
+	if err := errors.Join(cfg.loadEnv(), cfg.Validate()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		// go-toml cannot decode strings such as "15s" into time.Duration,
+		// so TOML is converted and decoded by the same YAML decoder.
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported config format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var env envLoader
+
+	env.string("SERVER_ADDR", &c.Server.Addr)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+
+	env.string("DATABASE_URL", &c.Database.URL)
+	env.string("DB_HOST", &c.Database.Host)
+	env.int("DB_PORT", &c.Database.Port)
+	env.string("DB_USER", &c.Database.User)
+	env.string("DB_PASSWORD", &c.Database.Password)
+	env.string("DB_NAME", &c.Database.Name)
+	env.string("DB_SSLMODE", &c.Database.SSLMode)
+	env.string("DB_SSLROOTCERT", &c.Database.SSLRootCert)
+	env.string("DB_SSLCERT", &c.Database.SSLCert)
+	env.string("DB_SSLKEY", &c.Database.SSLKey)
+	env.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	env.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	env.int("DB_CONNECT_RETRIES", &c.Database.ConnectRetries)
+	env.duration("DB_CONNECT_BACKOFF", &c.Database.ConnectBackoff)
+	env.duration("DB_CONNECT_MAX_BACKOFF", &c.Database.ConnectMaxBackoff)
+	env.string("DB_MIGRATIONS_SOURCE", &c.Database.MigrationsSource)
+	env.bool("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	env.string("AGE_API_URL", &c.Enrichment.AgeAPIURL)
+	env.string("GENDER_API_URL", &c.Enrichment.GenderAPIURL)
+	env.string("NATIONALITY_API_URL", &c.Enrichment.NationalityAPIURL)
+	env.int("ENRICHMENT_QUOTA_RESERVE", &c.Enrichment.QuotaReserve)
+	env.duration("ENRICHMENT_QUOTA_RESET_INTERVAL", &c.Enrichment.QuotaResetInterval)
+	env.string("ENRICHMENT_API_KEY", &c.Enrichment.APIKey)
+	env.bool("ENRICHMENT_COUNTRY_HINTS", &c.Enrichment.CountryHints)
+	env.bool("ENRICHMENT_GENDER_RULES", &c.Enrichment.GenderRules)
+	env.string("ENRICHMENT_NAME_SCRIPT", &c.Enrichment.NameScript)
+	env.duration("ENRICHMENT_HTTP_TIMEOUT", &c.Enrichment.HTTPTimeout)
+	env.string("ENRICHMENT_USER_AGENT", &c.Enrichment.UserAgent)
+	env.string("ENRICHMENT_PROXY_URL", &c.Enrichment.ProxyURL)
+	env.string("ENRICHMENT_CA_CERT_FILE", &c.Enrichment.CACertFile)
+	env.bool("ENRICHMENT_TLS_INSECURE_SKIP_VERIFY", &c.Enrichment.TLSInsecureSkipVerify)
+	env.int("ENRICHMENT_RETRY_ATTEMPTS", &c.Enrichment.RetryAttempts)
+	env.duration("ENRICHMENT_RETRY_BACKOFF", &c.Enrichment.RetryBackoff)
+	env.duration("ENRICHMENT_RETRY_MAX_BACKOFF", &c.Enrichment.RetryMaxBackoff)
+	env.int("ENRICHMENT_BREAKER_THRESHOLD", &c.Enrichment.BreakerThreshold)
+	env.duration("ENRICHMENT_BREAKER_OPEN_TIMEOUT", &c.Enrichment.BreakerOpenTimeout)
+	env.int("ENRICHMENT_AGE_MIN_COUNT", &c.Enrichment.Thresholds.Age.MinCount)
+	env.float("ENRICHMENT_GENDER_MIN_PROBABILITY", &c.Enrichment.Thresholds.Gender.MinProbability)
+	env.int("ENRICHMENT_GENDER_MIN_COUNT", &c.Enrichment.Thresholds.Gender.MinCount)
+	env.float("ENRICHMENT_NATIONALITY_MIN_PROBABILITY", &c.Enrichment.Thresholds.Nationality.MinProbability)
+	env.int("ENRICHMENT_NATIONALITY_MIN_COUNT", &c.Enrichment.Thresholds.Nationality.MinCount)
+	env.string("ENRICHMENT_LOW_CONFIDENCE", &c.Enrichment.LowConfidence)
+	env.string("ENRICHMENT_OFFLINE_MODE", &c.Enrichment.Offline.Mode)
+	env.string("ENRICHMENT_OFFLINE_FILE", &c.Enrichment.Offline.File)
+	env.chain("ENRICHMENT_AGE_CHAIN", &c.Enrichment.Chains.Age)
+	env.chain("ENRICHMENT_GENDER_CHAIN", &c.Enrichment.Chains.Gender)
+	env.chain("ENRICHMENT_NATIONALITY_CHAIN", &c.Enrichment.Chains.Nationality)
+	env.duration("ENRICHMENT_CACHE_TTL", &c.Enrichment.Cache.TTL)
+	env.int("ENRICHMENT_CACHE_MAX_ENTRIES", &c.Enrichment.Cache.MaxEntries)
+
+	env.bool("READYZ_CHECK_PROVIDERS", &c.Health.CheckProviders)
+	env.duration("READYZ_PROVIDERS_CACHE_TTL", &c.Health.ProvidersCacheTTL)
+
+	env.bool("AUTH_ENABLED", &c.Auth.Enabled)
+	env.string("AUTH_API_KEY_HEADER", &c.Auth.APIKeyHeader)
+	env.apiKeys("AUTH_API_KEYS", &c.Auth.APIKeys)
+	env.string("AUTH_JWT_HMAC_SECRET", &c.Auth.JWT.HMACSecret)
+	env.string("AUTH_JWT_JWKS_FILE", &c.Auth.JWT.JWKSFile)
+	env.string("AUTH_JWT_ISSUER", &c.Auth.JWT.Issuer)
+	env.string("AUTH_JWT_AUDIENCE", &c.Auth.JWT.Audience)
+	env.string("AUTH_JWT_ROLES_CLAIM", &c.Auth.JWT.RolesClaim)
+	env.roles("AUTH_ROLES", &c.Auth.Roles)
+
+	env.bool("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	env.string("RATE_LIMIT_BACKEND", &c.RateLimit.Backend)
+	env.limit("RATE_LIMIT_READ", &c.RateLimit.Read)
+	env.limit("RATE_LIMIT_WRITE", &c.RateLimit.Write)
+	env.limit("RATE_LIMIT_ENRICH", &c.RateLimit.Enrich)
+
+	env.bool("OUTBOX_ENABLED", &c.Outbox.Enabled)
+	env.string("OUTBOX_SINK", &c.Outbox.Sink)
+	env.duration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	env.int("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	env.duration("OUTBOX_RETRY_BACKOFF", &c.Outbox.RetryBackoff)
+	env.duration("OUTBOX_RETRY_MAX_BACKOFF", &c.Outbox.RetryMaxBackoff)
+	env.duration("OUTBOX_RETENTION", &c.Outbox.Retention)
+	env.string("OUTBOX_WEBHOOK_URL", &c.Outbox.Webhook.URL)
+	env.duration("OUTBOX_WEBHOOK_TIMEOUT", &c.Outbox.Webhook.Timeout)
+	env.string("OUTBOX_NATS_URL", &c.Outbox.NATS.URL)
+	env.string("OUTBOX_NATS_SUBJECT_PREFIX", &c.Outbox.NATS.SubjectPrefix)
+
+	env.bool("WEBHOOKS_ENABLED", &c.Webhooks.Enabled)
+	env.duration("WEBHOOKS_POLL_INTERVAL", &c.Webhooks.PollInterval)
+	env.int("WEBHOOKS_BATCH_SIZE", &c.Webhooks.BatchSize)
+	env.int("WEBHOOKS_CONCURRENCY", &c.Webhooks.Concurrency)
+	env.duration("WEBHOOKS_TIMEOUT", &c.Webhooks.Timeout)
+	env.int("WEBHOOKS_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts)
+	env.duration("WEBHOOKS_RETRY_BACKOFF", &c.Webhooks.RetryBackoff)
+	env.duration("WEBHOOKS_RETRY_MAX_BACKOFF", &c.Webhooks.RetryMaxBackoff)
+	env.int("WEBHOOKS_DISABLE_AFTER", &c.Webhooks.DisableAfter)
+	env.duration("WEBHOOKS_RETENTION", &c.Webhooks.Retention)
+
+	env.bool("STREAM_ENABLED", &c.Stream.Enabled)
+	env.duration("STREAM_POLL_INTERVAL", &c.Stream.PollInterval)
+	env.int("STREAM_BUFFER_SIZE", &c.Stream.BufferSize)
+	env.duration("STREAM_HEARTBEAT", &c.Stream.Heartbeat)
+	env.int("STREAM_MAX_CLIENTS", &c.Stream.MaxClients)
+
+	env.bool("GRPC_ENABLED", &c.GRPC.Enabled)
+	env.string("GRPC_ADDR", &c.GRPC.Addr)
+	env.bool("GRPC_REFLECTION", &c.GRPC.Reflection)
+
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every invalid setting at once so a misconfigured
+// deployment can be fixed in a single pass.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Server.Addr == "" {
+		fail("server.addr", "must not be empty")
+	}
+	for _, d := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+		{"database.conn_max_lifetime", c.Database.ConnMaxLifetime},
+		{"database.conn_max_idle_time", c.Database.ConnMaxIdleTime},
+	} {
+		if d.value < 0 {
+			fail(d.field, "must not be negative, got %s", d.value)
+		}
+	}
+
+	if c.Database.URL != "" {
+		if u, err := url.Parse(c.Database.URL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			fail("database.url", "must be a postgres:// or postgresql:// URL")
+		}
+	} else {
+		if c.Database.Host == "" {
+			fail("database.host", "must not be empty")
+		}
+		if c.Database.Port < 1 || c.Database.Port > 65535 {
+			fail("database.port", "must be between 1 and 65535, got %d", c.Database.Port)
+		}
+		if c.Database.User == "" {
+			fail("database.user", "must not be empty")
+		}
+		if c.Database.Name == "" {
+			fail("database.name", "must not be empty")
+		}
+		switch c.Database.SSLMode {
+		case "disable", "require", "verify-ca", "verify-full":
+		default:
+			fail("database.sslmode", "must be one of disable, require, verify-ca, verify-full, got %q", c.Database.SSLMode)
+		}
+	}
+	if c.Database.MaxOpenConns < 0 {
+		fail("database.max_open_conns", "must not be negative, got %d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 {
+		fail("database.max_idle_conns", "must not be negative, got %d", c.Database.MaxIdleConns)
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("database.max_idle_conns", "must not exceed max_open_conns (%d), got %d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	}
+	if c.Database.MigrationsSource == "" {
+		fail("database.migrations_source", `must be "embed" or a source URL such as file://migrations`)
+	}
+	if c.Database.ConnectRetries < 0 {
+		fail("database.connect_retries", "must not be negative, got %d", c.Database.ConnectRetries)
+	}
+	if c.Database.ConnectBackoff <= 0 {
+		fail("database.connect_backoff", "must be positive, got %s", c.Database.ConnectBackoff)
+	}
+	if c.Database.ConnectMaxBackoff < c.Database.ConnectBackoff {
+		fail("database.connect_max_backoff", "must not be less than connect_backoff (%s), got %s", c.Database.ConnectBackoff, c.Database.ConnectMaxBackoff)
+	}
+
+	checkURL := func(field, raw string) {
+		if u, err := url.Parse(raw); err != nil || u.Scheme == "" || u.Host == "" {
+			fail(field, "must be an absolute URL, got %q", raw)
+		}
+	}
+	checkURL("enrichment.age_api_url", c.Enrichment.AgeAPIURL)
+	checkURL("enrichment.gender_api_url", c.Enrichment.GenderAPIURL)
+	checkURL("enrichment.nationality_api_url", c.Enrichment.NationalityAPIURL)
+	if c.Enrichment.QuotaReserve < 0 {
+		fail("enrichment.quota_reserve", "must not be negative, got %d", c.Enrichment.QuotaReserve)
+	}
+	if c.Enrichment.QuotaResetInterval <= 0 {
+		fail("enrichment.quota_reset_interval", "must be positive, got %s", c.Enrichment.QuotaResetInterval)
+	}
+	if c.Enrichment.NameScript != "original" && c.Enrichment.NameScript != "latin" {
+		fail("enrichment.name_script", `must be "original" or "latin", got %q`, c.Enrichment.NameScript)
+	}
+	if c.Enrichment.HTTPTimeout <= 0 {
+		fail("enrichment.http_timeout", "must be positive, got %s", c.Enrichment.HTTPTimeout)
+	}
+	if c.Enrichment.UserAgent == "" {
+		fail("enrichment.user_agent", "must not be empty")
+	}
+	if c.Enrichment.ProxyURL != "" {
+		checkURL("enrichment.proxy_url", c.Enrichment.ProxyURL)
+	}
+	if c.Enrichment.RetryAttempts < 1 {
+		fail("enrichment.retry_attempts", "must be at least 1, got %d", c.Enrichment.RetryAttempts)
+	}
+	if c.Enrichment.RetryBackoff <= 0 {
+		fail("enrichment.retry_backoff", "must be positive, got %s", c.Enrichment.RetryBackoff)
+	}
+	if c.Enrichment.RetryMaxBackoff < c.Enrichment.RetryBackoff {
+		fail("enrichment.retry_max_backoff", "must not be less than retry_backoff (%s), got %s", c.Enrichment.RetryBackoff, c.Enrichment.RetryMaxBackoff)
+	}
+	if c.Enrichment.BreakerThreshold < 1 {
+		fail("enrichment.breaker_threshold", "must be at least 1, got %d", c.Enrichment.BreakerThreshold)
+	}
+	if c.Enrichment.BreakerOpenTimeout <= 0 {
+		fail("enrichment.breaker_open_timeout", "must be positive, got %s", c.Enrichment.BreakerOpenTimeout)
+	}
+	thresholds := []struct {
+		field string
+		value ThresholdConfig
+	}{
+		{"enrichment.thresholds.age", c.Enrichment.Thresholds.Age},
+		{"enrichment.thresholds.gender", c.Enrichment.Thresholds.Gender},
+		{"enrichment.thresholds.nationality", c.Enrichment.Thresholds.Nationality},
+	}
+	for _, t := range thresholds {
+		if t.value.MinProbability < 0 || t.value.MinProbability > 1 {
+			fail(t.field+".min_probability", "must be between 0 and 1, got %g", t.value.MinProbability)
+		}
+		if t.value.MinCount < 0 {
+			fail(t.field+".min_count", "must not be negative, got %d", t.value.MinCount)
+		}
+	}
+	if c.Enrichment.Thresholds.Age.MinProbability != 0 {
+		fail("enrichment.thresholds.age.min_probability", "is not supported, agify reports no probability")
+	}
+	if c.Enrichment.LowConfidence != "null" && c.Enrichment.LowConfidence != "mark" {
+		fail("enrichment.low_confidence", `must be "null" or "mark", got %q`, c.Enrichment.LowConfidence)
+	}
+	switch c.Enrichment.Offline.Mode {
+	case "off", "primary", "fallback":
+	default:
+		fail("enrichment.offline.mode", `must be "off", "primary" or "fallback", got %q`, c.Enrichment.Offline.Mode)
+	}
+	chains := []struct {
+		field string
+		links []ChainLinkConfig
+	}{
+		{"enrichment.chains.age", c.Enrichment.Chains.Age},
+		{"enrichment.chains.gender", c.Enrichment.Chains.Gender},
+		{"enrichment.chains.nationality", c.Enrichment.Chains.Nationality},
+	}
+	usesOffline := false
+	for _, ch := range chains {
+		seen := make(map[string]bool)
+		for i, link := range c.Enrichment.ResolvedChain(ch.links) {
+			field := fmt.Sprintf("%s[%d]", ch.field, i)
+			switch link.Source {
+			case SourceOffline:
+				usesOffline = true
+			case SourceAPI, SourceCache:
+			default:
+				fail(field+".source", `must be "api", "cache" or "offline", got %q`, link.Source)
+			}
+			if seen[link.Source] {
+				fail(field+".source", "duplicate source %q", link.Source)
+			}
+			seen[link.Source] = true
+			if link.Timeout < 0 {
+				fail(field+".timeout", "must not be negative, got %s", link.Timeout)
+			}
+		}
+	}
+	if usesOffline {
+		switch ext := strings.ToLower(filepath.Ext(c.Enrichment.Offline.File)); {
+		case c.Enrichment.Offline.File == "":
+			fail("enrichment.offline.file", "must be set when the offline dataset is used")
+		case ext != ".csv" && ext != ".json":
+			fail("enrichment.offline.file", "must be a .csv or .json file, got %q", c.Enrichment.Offline.File)
+		}
+	}
+	if c.Enrichment.Cache.TTL <= 0 {
+		fail("enrichment.cache.ttl", "must be positive, got %s", c.Enrichment.Cache.TTL)
+	}
+	if c.Enrichment.Cache.MaxEntries < 1 {
+		fail("enrichment.cache.max_entries", "must be at least 1, got %d", c.Enrichment.Cache.MaxEntries)
+	}
+
+	if c.Health.ProvidersCacheTTL < 0 {
+		fail("health.providers_cache_ttl", "must not be negative, got %s", c.Health.ProvidersCacheTTL)
+	}
+	if c.Auth.Enabled {
+		if len(c.Auth.APIKeys) == 0 && c.Auth.JWT.HMACSecret == "" && c.Auth.JWT.JWKSFile == "" {
+			fail("auth", "enabled but neither api_keys nor jwt.hmac_secret/jwt.jwks_file is configured")
+		}
+		if len(c.Auth.APIKeys) > 0 && c.Auth.APIKeyHeader == "" {
+			fail("auth.api_key_header", "must not be empty")
+		}
+		if c.Auth.JWT.HMACSecret != "" && len(c.Auth.JWT.HMACSecret) < 32 {
+			fail("auth.jwt.hmac_secret", "must be at least 32 bytes long")
+		}
+	}
+	names := make(map[string]bool)
+	for i, k := range c.Auth.APIKeys {
+		field := fmt.Sprintf("auth.api_keys[%d]", i)
+		if k.Name == "" {
+			fail(field+".name", "must not be empty")
+		} else if names[k.Name] {
+			fail(field+".name", "duplicate key name %q", k.Name)
+		}
+		names[k.Name] = true
+		if !isSHA256Hash(k.Hash) {
+			fail(field+".hash", `must be "sha256:" followed by 64 hex digits`)
+		}
+		for _, role := range k.Roles {
+			if _, ok := c.Auth.Roles[role]; !ok && !isPermission(role) {
+				fail(field+".roles", "unknown role %q", role)
+			}
+		}
+	}
+	for role, perms := range c.Auth.Roles {
+		for _, perm := range perms {
+			if !isPermission(perm) {
+				fail("auth.roles."+role, "unknown permission %q, want persons:read, persons:write, persons:delete, metrics:read or admin", perm)
+			}
+		}
+	}
+	if c.Auth.Enabled && c.Auth.JWT.RolesClaim == "" && (c.Auth.JWT.HMACSecret != "" || c.Auth.JWT.JWKSFile != "") {
+		fail("auth.jwt.roles_claim", "must not be empty")
+	}
+
+	switch c.RateLimit.Backend {
+	case "memory", "postgres":
+	default:
+		fail("rate_limit.backend", `must be "memory" or "postgres", got %q`, c.RateLimit.Backend)
+	}
+	for _, l := range []struct {
+		field string
+		limit LimitConfig
+	}{
+		{"rate_limit.read", c.RateLimit.Read},
+		{"rate_limit.write", c.RateLimit.Write},
+		{"rate_limit.enrich", c.RateLimit.Enrich},
+	} {
+		if l.limit.Requests <= 0 || l.limit.Period <= 0 || l.limit.Burst <= 0 {
+			fail(l.field, "requests, period and burst must all be positive")
+		}
+	}
+
+	if c.Outbox.Enabled {
+		switch c.Outbox.Sink {
+		case SinkNone, SinkStdout:
+		case SinkWebhook:
+			checkURL("outbox.webhook.url", c.Outbox.Webhook.URL)
+			if c.Outbox.Webhook.Timeout <= 0 {
+				fail("outbox.webhook.timeout", "must be positive, got %s", c.Outbox.Webhook.Timeout)
+			}
+		case SinkNATS:
+			checkURL("outbox.nats.url", c.Outbox.NATS.URL)
+			if c.Outbox.NATS.SubjectPrefix == "" {
+				fail("outbox.nats.subject_prefix", "must not be empty")
+			}
+		default:
+			fail("outbox.sink", `must be "none", "stdout", "webhook" or "nats", got %q`, c.Outbox.Sink)
+		}
+		if c.Outbox.PollInterval <= 0 {
+			fail("outbox.poll_interval", "must be positive, got %s", c.Outbox.PollInterval)
+		}
+		if c.Outbox.BatchSize < 1 {
+			fail("outbox.batch_size", "must be at least 1, got %d", c.Outbox.BatchSize)
+		}
+		if c.Outbox.RetryBackoff <= 0 {
+			fail("outbox.retry_backoff", "must be positive, got %s", c.Outbox.RetryBackoff)
+		}
+		if c.Outbox.RetryMaxBackoff < c.Outbox.RetryBackoff {
+			fail("outbox.retry_max_backoff", "must not be less than retry_backoff (%s), got %s", c.Outbox.RetryBackoff, c.Outbox.RetryMaxBackoff)
+		}
+		if c.Outbox.Retention <= 0 {
+			fail("outbox.retention", "must be positive, got %s", c.Outbox.Retention)
+		}
+	}
+
+	if c.Webhooks.Enabled {
+		if !c.Outbox.Enabled {
+			fail("webhooks.enabled", "requires outbox.enabled, the deliveries are fed from the outbox")
+		}
+		for _, n := range []struct {
+			field string
+			value int
+		}{
+			{"webhooks.batch_size", c.Webhooks.BatchSize},
+			{"webhooks.concurrency", c.Webhooks.Concurrency},
+			{"webhooks.max_attempts", c.Webhooks.MaxAttempts},
+			{"webhooks.disable_after", c.Webhooks.DisableAfter},
+		} {
+			if n.value < 1 {
+				fail(n.field, "must be at least 1, got %d", n.value)
+			}
+		}
+		for _, d := range []struct {
+			field string
+			value time.Duration
+		}{
+			{"webhooks.poll_interval", c.Webhooks.PollInterval},
+			{"webhooks.timeout", c.Webhooks.Timeout},
+			{"webhooks.retry_backoff", c.Webhooks.RetryBackoff},
+			{"webhooks.retention", c.Webhooks.Retention},
+		} {
+			if d.value <= 0 {
+				fail(d.field, "must be positive, got %s", d.value)
+			}
+		}
+		if c.Webhooks.RetryMaxBackoff < c.Webhooks.RetryBackoff {
+			fail("webhooks.retry_max_backoff", "must not be less than retry_backoff (%s), got %s", c.Webhooks.RetryBackoff, c.Webhooks.RetryMaxBackoff)
+		}
+	}
+
+	if c.Stream.Enabled {
+		if !c.Outbox.Enabled {
+			fail("stream.enabled", "requires outbox.enabled, the stream is read from the outbox")
+		}
+		if c.Stream.PollInterval <= 0 {
+			fail("stream.poll_interval", "must be positive, got %s", c.Stream.PollInterval)
+		}
+		if c.Stream.BufferSize < 1 {
+			fail("stream.buffer_size", "must be at least 1, got %d", c.Stream.BufferSize)
+		}
+		if c.Stream.Heartbeat <= 0 {
+			fail("stream.heartbeat", "must be positive, got %s", c.Stream.Heartbeat)
+		}
+		if c.Stream.MaxClients < 1 {
+			fail("stream.max_clients", "must be at least 1, got %d", c.Stream.MaxClients)
+		}
+	}
+
+	if c.GRPC.Enabled {
+		switch c.GRPC.Addr {
+		case "":
+			fail("grpc.addr", "must not be empty")
+		case c.Server.Addr:
+			fail("grpc.addr", "must differ from server.addr %q", c.Server.Addr)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func isSHA256Hash(s string) bool {
+	digest, ok := strings.CutPrefix(strings.ToLower(s), "sha256:")
+	if !ok || len(digest) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+func isPermission(s string) bool {
+	switch s {
+	case "persons:read", "persons:write", "persons:delete", "metrics:read", "admin":
+		return true
+	}
+	return false
+}
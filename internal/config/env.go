@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envLoader overrides config fields from environment variables and collects
+// parse errors instead of stopping at the first one.
+type envLoader struct {
+	errs []error
+}
+
+// lookup treats an empty variable as unset, so the empty entries of
+// .env.template do not blank out values from the config file.
+func (l *envLoader) lookup(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
+
+func (l *envLoader) fail(key, value, kind string) {
+	l.errs = append(l.errs, fmt.Errorf("%s: invalid %s %q", key, kind, value))
+}
+
+func (l *envLoader) string(key string, dst *string) {
+	if value, ok := l.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (l *envLoader) int(key string, dst *int) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(key, value, "integer")
+		return
+	}
+	*dst = n
+}
+
+func (l *envLoader) float(key string, dst *float64) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.fail(key, value, "number")
+		return
+	}
+	*dst = f
+}
+
+func (l *envLoader) bool(key string, dst *bool) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail(key, value, "boolean")
+		return
+	}
+	*dst = b
+}
+
+func (l *envLoader) duration(key string, dst *time.Duration) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.fail(key, value, "duration")
+		return
+	}
+	*dst = d
+}
+
+// apiKeys parses comma-separated "name=sha256:<hex>[:role1|role2]" entries.
+func (l *envLoader) apiKeys(key string, dst *[]APIKeyConfig) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	var keys []APIKeyConfig
+	for _, entry := range splitList(value, ",") {
+		name, hash, ok := strings.Cut(entry, "=")
+		if !ok {
+			l.fail(key, entry, "API key entry (want name=sha256:<hex>[:role1|role2])")
+			continue
+		}
+		var roles []string
+		if strings.Count(hash, ":") > 1 {
+			i := strings.LastIndex(hash, ":")
+			hash, roles = hash[:i], splitList(hash[i+1:], "|")
+		}
+		keys = append(keys, APIKeyConfig{Name: strings.TrimSpace(name), Hash: strings.TrimSpace(hash), Roles: roles})
+	}
+	*dst = keys
+}
+
+// roles parses comma-separated "role=perm1|perm2" entries. The result
+// replaces the default role table entirely.
+func (l *envLoader) roles(key string, dst *map[string][]string) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	roles := make(map[string][]string)
+	for _, entry := range splitList(value, ",") {
+		role, perms, ok := strings.Cut(entry, "=")
+		if !ok {
+			l.fail(key, entry, "role entry (want role=perm1|perm2)")
+			continue
+		}
+		roles[strings.TrimSpace(role)] = splitList(perms, "|")
+	}
+	*dst = roles
+}
+
+func splitList(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// limit parses "requests/period/burst", e.g. "60/1m/10".
+func (l *envLoader) limit(key string, dst *LimitConfig) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 {
+		l.fail(key, value, "limit (want requests/period/burst, e.g. 60/1m/10)")
+		return
+	}
+	requests, errRequests := strconv.Atoi(parts[0])
+	period, errPeriod := time.ParseDuration(parts[1])
+	burst, errBurst := strconv.Atoi(parts[2])
+	if errRequests != nil || errPeriod != nil || errBurst != nil {
+		l.fail(key, value, "limit (want requests/period/burst, e.g. 60/1m/10)")
+		return
+	}
+	*dst = LimitConfig{Requests: requests, Period: period, Burst: burst}
+}
+
+// chain parses "source[:timeout],...", e.g. "api:3s,cache,offline".
+func (l *envLoader) chain(key string, dst *[]ChainLinkConfig) {
+	value, ok := l.lookup(key)
+	if !ok {
+		return
+	}
+	var links []ChainLinkConfig
+	for _, entry := range splitList(value, ",") {
+		source, timeout, hasTimeout := strings.Cut(entry, ":")
+		link := ChainLinkConfig{Source: strings.TrimSpace(source)}
+		if hasTimeout {
+			d, err := time.ParseDuration(strings.TrimSpace(timeout))
+			if err != nil {
+				l.fail(key, value, "chain (want source[:timeout],..., e.g. api:3s,cache,offline)")
+				return
+			}
+			link.Timeout = d
+		}
+		links = append(links, link)
+	}
+	*dst = links
+}
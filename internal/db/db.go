@@ -2,85 +2,81 @@ package db
 
 import (
 	"database/sql"
-	"fmt"
-	"os"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
-	migrate "github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/joho/godotenv"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
-func InitDB() (*sql.DB, error) {
-	err := godotenv.Load()
-	if err != nil {
-		logrus.Warn("Error loading .env file, using system environment variables")
-	}
-
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		GetEnv("DB_HOST", "localhost"),
-		GetEnv("DB_PORT", "5432"),
-		GetEnv("DB_USER", "postgres"),
-		GetEnv("DB_PASSWORD", "secret"),
-		GetEnv("DB_NAME", "persons_db"),
-	)
-
-	db, err := sql.Open("postgres", connStr)
+// InitDB opens the connection pool and pings Postgres, retrying with
+// exponential backoff so the service can start before the database is up.
+func InitDB(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", DSN(cfg))
 	if err != nil {
 		logrus.WithError(err).Error("Failed to open database connection")
 		return nil, err
 	}
 
-	err = db.Ping()
-	if err != nil {
-		logrus.WithError(err).Error("Failed to ping database")
-		return nil, err
-	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	backoff := cfg.ConnectBackoff
+	for attempt := 0; ; attempt++ {
+		err = db.Ping()
+		if err == nil {
+			break
+		}
+		if attempt >= cfg.ConnectRetries {
+			logrus.WithError(err).Error("Failed to ping database")
+			db.Close()
+			return nil, err
+		}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+		logrus.WithFields(logrus.Fields{
+			"attempt": attempt + 1,
+			"retries": cfg.ConnectRetries,
+			"backoff": backoff.String(),
+			"error":   err,
+		}).Warn("Database is not reachable yet, retrying")
+		time.Sleep(backoff)
+		backoff = min(backoff*2, cfg.ConnectMaxBackoff)
+	}
 
 	logrus.Info("Successfully connected to database")
 	return db, nil
 }
 
-func RunMigrations(db *sql.DB) error {
-	logrus.Info("Starting database migrations")
-
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		logrus.WithError(err).Error("Failed to create migration driver")
-		return err
+// DSN returns cfg.URL when set, otherwise builds a postgres:// URL so that
+// credentials containing spaces or special characters are escaped.
+func DSN(cfg config.DatabaseConfig) string {
+	if cfg.URL != "" {
+		return cfg.URL
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
-		driver,
-	)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to initialize migrations")
-		return err
+	params := url.Values{}
+	params.Set("sslmode", cfg.SSLMode)
+	if cfg.SSLRootCert != "" {
+		params.Set("sslrootcert", cfg.SSLRootCert)
 	}
-
-	err = m.Up()
-	if err != nil && err != migrate.ErrNoChange {
-		logrus.WithError(err).Error("Failed to apply migrations")
-		return err
+	if cfg.SSLCert != "" {
+		params.Set("sslcert", cfg.SSLCert)
+	}
+	if cfg.SSLKey != "" {
+		params.Set("sslkey", cfg.SSLKey)
 	}
 
-	logrus.Info("Database migrations completed")
-	return nil
-}
-
-func GetEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: params.Encode(),
 	}
-	return defaultValue
+	return u.String()
 }
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/migrations"
+	migrate "github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/sirupsen/logrus"
+)
+
+// EmbeddedMigrations selects the migrations compiled into the binary.
+const EmbeddedMigrations = "embed"
+
+var ErrNoMigrations = errors.New("no migrations applied")
+
+// NewMigrator prepares a migrate instance on a dedicated connection from the
+// pool, so closing it does not close db. source is either EmbeddedMigrations
+// or a golang-migrate source URL such as file://migrations.
+func NewMigrator(db *sql.DB, source string) (*migrate.Migrate, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(context.Background(), conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create migration driver: %w", err)
+	}
+
+	var m *migrate.Migrate
+	if source == "" || source == EmbeddedMigrations {
+		src, srcErr := iofs.New(migrations.FS, ".")
+		if srcErr != nil {
+			driver.Close()
+			return nil, fmt.Errorf("open embedded migrations: %w", srcErr)
+		}
+		m, err = migrate.NewWithInstance("iofs", src, "postgres", driver)
+	} else {
+		m, err = migrate.NewWithDatabaseInstance(source, "postgres", driver)
+	}
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("initialize migrations: %w", err)
+	}
+
+	m.Log = migrateLogger{}
+	return m, nil
+}
+
+func RunMigrations(db *sql.DB, source string) error {
+	logrus.WithField("source", source).Info("Starting database migrations")
+
+	m, err := NewMigrator(db, source)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to initialize migrations")
+		return err
+	}
+	defer m.Close()
+
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		logrus.WithError(err).Error("Failed to apply migrations")
+		return err
+	}
+
+	logrus.Info("Database migrations completed")
+	return nil
+}
+
+func MigrationStatus(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version uint
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, ErrNoMigrations
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// LatestMigration returns the highest migration version in source, which is
+// EmbeddedMigrations or a golang-migrate source URL as for NewMigrator.
+func LatestMigration(src string) (uint, error) {
+	var driver source.Driver
+	var err error
+	if src == "" || src == EmbeddedMigrations {
+		driver, err = iofs.New(migrations.FS, ".")
+	} else {
+		driver, err = source.Open(src)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open migrations: %w", err)
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	logrus.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ProviderQuota is the last known request allowance of an enrichment API.
+// Unknown values are omitted until the provider has answered at least once.
+type ProviderQuota struct {
+	Provider  string     `json:"provider" example:"agify"`
+	Limit     *int       `json:"limit,omitempty" example:"1000"`
+	Remaining *int       `json:"remaining,omitempty" example:"420"`
+	Reserve   int        `json:"reserve" example:"5"`
+	ResetAt   *time.Time `json:"reset_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Throttled bool       `json:"throttled"`
+}
+
+// ReEnrichRequest selects the persons a re-enrichment job refreshes. With
+// neither field set every person is re-enriched.
+type ReEnrichRequest struct {
+	// IDs limits the job to these persons.
+	IDs []int `json:"ids,omitempty" example:"1,2,3"`
+	// OnlyMissing fills only attributes that are still empty and leaves
+	// the others untouched.
+	OnlyMissing bool `json:"only_missing" example:"true"`
+}
+
+// EnrichmentJob reports the progress of a background re-enrichment job.
+type EnrichmentJob struct {
+	ID          int        `json:"id" example:"1"`
+	Status      string     `json:"status" enums:"running,completed,failed,canceled" example:"running"`
+	OnlyMissing bool       `json:"only_missing"`
+	Processed   int        `json:"processed" example:"250"`
+	Updated     int        `json:"updated" example:"240"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Enrichment records how each predicted attribute of a person was decided,
+// so operators can see why a value is missing and tune the thresholds.
+type Enrichment struct {
+	Age         *AttributeEnrichment `json:"age,omitempty"`
+	Gender      *AttributeEnrichment `json:"gender,omitempty"`
+	Nationality *AttributeEnrichment `json:"nationality,omitempty"`
+}
+
+// Enrichment statuses. A rejected prediction fell below the thresholds and
+// was not stored, a low_confidence one was stored anyway.
+const (
+	EnrichmentAccepted      = "accepted"
+	EnrichmentLowConfidence = "low_confidence"
+	EnrichmentRejected      = "rejected"
+	EnrichmentUnavailable   = "unavailable"
+)
+
+type AttributeEnrichment struct {
+	Status   string `json:"status" enums:"accepted,low_confidence,rejected,unavailable" example:"rejected"`
+	Provider string `json:"provider" example:"genderize"`
+	// Rule is the name ending that decided a rule-based prediction.
+	Rule string `json:"rule,omitempty" example:"patronymic -овна"`
+	// Value is the prediction, also when it was rejected.
+	Value       interface{} `json:"value,omitempty" swaggertype:"string" example:"female"`
+	Probability *float64    `json:"probability,omitempty" example:"0.52"`
+	Count       *int        `json:"count,omitempty" example:"1834"`
+	Reason      string      `json:"reason,omitempty" example:"probability 0.52 is below 0.80"`
+}
+
+// Value stores Enrichment in the JSONB enrichment column.
+func (e Enrichment) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+// Scan reads Enrichment from the JSONB enrichment column.
+func (e *Enrichment) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, e)
+	case string:
+		return json.Unmarshal([]byte(v), e)
+	default:
+		return fmt.Errorf("cannot scan %T into Enrichment", src)
+	}
+}
+
+// OfflineDataset describes the loaded offline enrichment dataset.
+type OfflineDataset struct {
+	File     string    `json:"file" example:"/data/names.csv"`
+	Entries  int       `json:"entries" example:"25000"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
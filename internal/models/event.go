@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Person event types written to the outbox.
+const (
+	EventPersonCreated  = "PersonCreated"
+	EventPersonUpdated  = "PersonUpdated"
+	EventPersonEnriched = "PersonEnriched"
+	EventPersonDeleted  = "PersonDeleted"
+)
+
+// PersonEvent reports a change to a person. Delivery is at least once, so
+// consumers should skip IDs they have already seen.
+type PersonEvent struct {
+	ID       int64  `json:"id" example:"42"`
+	Type     string `json:"type" enums:"PersonCreated,PersonUpdated,PersonEnriched,PersonDeleted"`
+	PersonID int    `json:"person_id" example:"7"`
+	// Person is the state after the change; for PersonDeleted it is the
+	// last state before the deletion.
+	Person     *Person   `json:"person,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
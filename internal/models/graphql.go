@@ -0,0 +1,21 @@
+package models
+
+// GraphQLRequest is a GraphQL query or mutation sent to POST /graphql.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required" example:"{ persons(filter: {nationality: \"RU\"}, limit: 5) { id name enrichment { age { status probability } } } }"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse documents the result of POST /graphql. Data holds the
+// requested fields; fields that failed are null and explained in Errors.
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message    string            `json:"message" example:"Person not found"`
+	Path       []interface{}     `json:"path,omitempty" swaggertype:"array,string"`
+	Extensions map[string]string `json:"extensions,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+type DependencyStatus struct {
+	Status  string `json:"status" enums:"up,down,skipped"`
+	Details string `json:"details,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status       string                      `json:"status" enums:"ready,degraded,not_ready"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
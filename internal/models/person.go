@@ -8,12 +8,71 @@ type Person struct {
 	Age         *int    `json:"age,omitempty"`
 	Gender      *string `json:"gender,omitempty" enums:"male,female,other"`
 	Nationality *string `json:"nationality,omitempty"`
+	// The Latin transliterations (ICAO Doc 9303) of the name parts, derived
+	// on every write and used for search in either script.
+	NameLatin       *string `json:"name_latin,omitempty" example:"Dmitrii"`
+	SurnameLatin    *string `json:"surname_latin,omitempty" example:"Ushakov"`
+	PatronymicLatin *string `json:"patronymic_latin,omitempty" example:"Vasilevich"`
+	// Enrichment explains how age, gender and nationality were predicted.
+	Enrichment *Enrichment `json:"enrichment,omitempty"`
 }
 
 type PersonRequest struct {
-	Name       string  `json:"name" binding:"required"`
-	Surname    string  `json:"surname" binding:"required"`
+	Name       string  `json:"name" binding:"required_without=FullName"`
+	Surname    string  `json:"surname" binding:"required_without=FullName"`
 	Patronymic *string `json:"patronymic,omitempty"`
+	// FullName replaces name, surname and patronymic with a single string
+	// that is split as by POST /persons/parse.
+	FullName string `json:"full_name,omitempty" example:"Иванов Иван Иванович"`
+	// CountryID is an ISO 3166-1 alpha-2 hint that sharpens the age and
+	// gender predictions.
+	CountryID *string `json:"country_id,omitempty" binding:"omitempty,iso3166_1_alpha2" example:"RU"`
+}
+
+// BulkPersonRequest imports several persons at once. Their names are
+// enriched in batches and all persons are inserted in one transaction.
+type BulkPersonRequest struct {
+	Persons []PersonRequest `json:"persons" binding:"required,min=1,max=1000,dive"`
+}
+
+// ParseRequest is a full name in a single string, in either order and
+// script.
+type ParseRequest struct {
+	FullName string `json:"full_name" binding:"required" example:"Ivan Ivanovich Ivanov"`
+}
+
+// ParsedName is a full name split into its normalized components.
+// Confidence is between 0 and 1; Reason names the rule that decided the
+// order.
+type ParsedName struct {
+	Name       string  `json:"name" example:"Ivan"`
+	Surname    string  `json:"surname" example:"Ivanov"`
+	Patronymic *string `json:"patronymic,omitempty" example:"Ivanovich"`
+	Order      string  `json:"order" example:"name patronymic surname"`
+	Confidence float64 `json:"confidence" example:"0.95"`
+	Reason     string  `json:"reason" example:"patronymic ending in second word"`
+}
+
+// DeclinedName is a full name in one grammatical case.
+type DeclinedName struct {
+	Surname    string  `json:"surname" example:"Иванову"`
+	Name       string  `json:"name" example:"Ивану"`
+	Patronymic *string `json:"patronymic,omitempty" example:"Ивановичу"`
+	FullName   string  `json:"full_name" example:"Иванову Ивану Ивановичу"`
+}
+
+// Declension is a person's full name in the six Russian cases.
+// GenderSource is "person" when the stored gender picked the paradigms and
+// "rules" when it was inferred from the patronymic or surname.
+type Declension struct {
+	Gender        string       `json:"gender" enums:"male,female"`
+	GenderSource  string       `json:"gender_source" enums:"person,rules"`
+	Nominative    DeclinedName `json:"nominative"`
+	Genitive      DeclinedName `json:"genitive"`
+	Dative        DeclinedName `json:"dative"`
+	Accusative    DeclinedName `json:"accusative"`
+	Instrumental  DeclinedName `json:"instrumental"`
+	Prepositional DeclinedName `json:"prepositional"`
 }
 
 type PersonPatch struct {
@@ -28,3 +87,9 @@ type PersonPatch struct {
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+type ForbiddenResponse struct {
+	Error              string `json:"error" example:"Forbidden"`
+	Detail             string `json:"detail" example:"principal analyst lacks permission persons:delete"`
+	RequiredPermission string `json:"required_permission" example:"persons:delete"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// WebhookSubscription receives the person events listed in EventTypes. It
+// is disabled after too many consecutive failed deliveries and enabled
+// again by updating it with active set.
+type WebhookSubscription struct {
+	ID         int      `json:"id" example:"1"`
+	URL        string   `json:"url" example:"https://partner.example.com/hooks/persons"`
+	EventTypes []string `json:"event_types" example:"PersonEnriched"`
+	// Secret signs the deliveries. It is returned only when the
+	// subscription is created.
+	Secret              string    `json:"secret,omitempty" example:"3f9a0c4e5b7d1a2c3e4f5a6b7c8d9e0f"`
+	Active              bool      `json:"active"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	DisabledReason      *string   `json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// WebhookSubscriptionRequest creates or replaces a subscription. A secret
+// is generated when none is given.
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required,url" example:"https://partner.example.com/hooks/persons"`
+	EventTypes []string `json:"event_types" binding:"required,min=1,dive,oneof=PersonCreated PersonUpdated PersonEnriched PersonDeleted" example:"PersonEnriched"`
+	Secret     string   `json:"secret,omitempty" binding:"omitempty,min=16"`
+	// Active defaults to true. Setting it re-enables a disabled
+	// subscription and resets its failure count.
+	Active *bool `json:"active,omitempty"`
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a subscription.
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int        `json:"subscription_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status" enums:"pending,succeeded,failed"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
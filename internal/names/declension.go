@@ -0,0 +1,196 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DeclinedName is a full name in one grammatical case.
+type DeclinedName struct {
+	Surname    string
+	Name       string
+	Patronymic string
+}
+
+// Full joins the parts in the official order: surname, name, patronymic.
+func (d DeclinedName) Full() string {
+	return strings.Join(strings.Fields(d.Surname+" "+d.Name+" "+d.Patronymic), " ")
+}
+
+// Declension holds a full name in all six Russian cases.
+type Declension struct {
+	Nominative    DeclinedName
+	Genitive      DeclinedName
+	Dative        DeclinedName
+	Accusative    DeclinedName
+	Instrumental  DeclinedName
+	Prepositional DeclinedName
+}
+
+// paradigm replaces the last cut letters of a word with the genitive,
+// dative, accusative, instrumental and prepositional endings.
+type paradigm struct {
+	suffixes []string
+	cut      int
+	endings  [5]string
+}
+
+// Declension tables. The first paradigm whose suffix ends the word wins,
+// so longer suffixes come first; a word that matches none is indeclinable.
+// Nouns in -а/-я take -и rather than -ы after г, к, х and sibilants, and
+// an unstressed -ей rather than -ой after sibilants and ц.
+var (
+	aParadigms = []paradigm{
+		{[]string{"ия"}, 1, [5]string{"и", "и", "ю", "ей", "и"}},
+		{[]string{"я"}, 1, [5]string{"и", "е", "ю", "ей", "е"}},
+		{[]string{"жа", "ша", "ча", "ща"}, 1, [5]string{"и", "е", "у", "ей", "е"}},
+		{[]string{"ца"}, 1, [5]string{"ы", "е", "у", "ей", "е"}},
+		{[]string{"га", "ка", "ха"}, 1, [5]string{"и", "е", "у", "ой", "е"}},
+		{[]string{"а"}, 1, [5]string{"ы", "е", "у", "ой", "е"}},
+	}
+	sibilantParadigm  = paradigm{[]string{"ж", "ш", "ч", "щ", "ц"}, 0, [5]string{"а", "у", "а", "ем", "е"}}
+	consonantParadigm = paradigm{
+		[]string{"б", "в", "г", "д", "з", "к", "л", "м", "н", "п", "р", "с", "т", "ф", "х"},
+		0, [5]string{"а", "у", "а", "ом", "е"},
+	}
+	softParadigm = paradigm{[]string{"й", "ь"}, 1, [5]string{"я", "ю", "я", "ем", "е"}}
+
+	maleSurnameParadigms = concat([]paradigm{
+		{[]string{"ых", "их"}, 0, [5]string{"", "", "", "", ""}},
+		{[]string{"кий", "гий", "хий"}, 2, [5]string{"ого", "ому", "ого", "им", "ом"}},
+		{[]string{"ий"}, 2, [5]string{"его", "ему", "его", "им", "ем"}},
+		{[]string{"ый", "ой"}, 2, [5]string{"ого", "ому", "ого", "ым", "ом"}},
+		{[]string{"ов", "ев", "ёв", "ин", "ын"}, 0, [5]string{"а", "у", "а", "ым", "е"}},
+	}, aParadigms, []paradigm{sibilantParadigm, softParadigm, consonantParadigm})
+
+	femaleSurnameParadigms = concat([]paradigm{
+		{[]string{"ая"}, 2, [5]string{"ой", "ой", "ую", "ой", "ой"}},
+		{[]string{"яя"}, 2, [5]string{"ей", "ей", "юю", "ей", "ей"}},
+		{[]string{"ова", "ева", "ёва", "ина", "ына"}, 1, [5]string{"ой", "ой", "у", "ой", "ой"}},
+	}, aParadigms)
+
+	maleNameParadigms = concat([]paradigm{
+		{[]string{"ий"}, 1, [5]string{"я", "ю", "я", "ем", "и"}},
+	}, aParadigms, []paradigm{sibilantParadigm, softParadigm, consonantParadigm})
+
+	femaleNameParadigms = concat(aParadigms, []paradigm{
+		{[]string{"ь"}, 1, [5]string{"и", "и", "ь", "ью", "и"}},
+	})
+
+	malePatronymicParadigms = []paradigm{
+		{[]string{"ич"}, 0, [5]string{"а", "у", "а", "ем", "е"}},
+	}
+
+	femalePatronymicParadigms = []paradigm{
+		{[]string{"на"}, 1, [5]string{"ы", "е", "у", "ой", "е"}},
+	}
+)
+
+// nameExceptions lists first names with a fleeting vowel or a stressed
+// ending that the tables get wrong.
+var nameExceptions = map[string][5]string{
+	"павел": {"павла", "павлу", "павла", "павлом", "павле"},
+	"лев":   {"льва", "льву", "льва", "львом", "льве"},
+	"петр":  {"петра", "петру", "петра", "петром", "петре"},
+	"илья":  {"ильи", "илье", "илью", "ильёй", "илье"},
+}
+
+func concat(tables ...[]paradigm) []paradigm {
+	var all []paradigm
+	for _, t := range tables {
+		all = append(all, t...)
+	}
+	return all
+}
+
+// Decline puts a Russian full name into all six cases. gender is Male or
+// Female and picks the paradigms; names in Latin script, Turkic
+// patronymics and surnames such as Шевченко or Черных stay unchanged.
+func Decline(surname, name, patronymic, gender string) Declension {
+	surnames, names, patronymics := maleSurnameParadigms, maleNameParadigms, malePatronymicParadigms
+	if gender == Female {
+		surnames, names, patronymics = femaleSurnameParadigms, femaleNameParadigms, femalePatronymicParadigms
+	}
+
+	s := declineWord(surname, surnames, nil)
+	n := declineWord(name, names, nameExceptions)
+	p := declineWord(patronymic, patronymics, nil)
+	form := func(i int) DeclinedName {
+		return DeclinedName{Surname: s[i], Name: n[i], Patronymic: p[i]}
+	}
+	return Declension{
+		Nominative:    DeclinedName{Surname: surname, Name: name, Patronymic: patronymic},
+		Genitive:      form(0),
+		Dative:        form(1),
+		Accusative:    form(2),
+		Instrumental:  form(3),
+		Prepositional: form(4),
+	}
+}
+
+// declineWord returns the five oblique forms of word. Each part of a
+// hyphenated word is declined on its own, as in Римского-Корсакова; a
+// word of several space-separated parts is left unchanged.
+func declineWord(word string, paradigms []paradigm, exceptions map[string][5]string) [5]string {
+	var forms [5]string
+	if strings.Contains(word, " ") || !isCyrillic(word) {
+		for i := range forms {
+			forms[i] = word
+		}
+		return forms
+	}
+
+	for j, part := range strings.Split(word, "-") {
+		declined := declinePart(part, paradigms, exceptions)
+		for i := range forms {
+			if j > 0 {
+				forms[i] += "-"
+			}
+			forms[i] += declined[i]
+		}
+	}
+	return forms
+}
+
+func declinePart(part string, paradigms []paradigm, exceptions map[string][5]string) [5]string {
+	var forms [5]string
+	if e, ok := exceptions[fold(part)]; ok {
+		for i, f := range e {
+			forms[i] = matchCase(f, part)
+		}
+		return forms
+	}
+
+	folded := fold(part)
+	for _, p := range paradigms {
+		for _, suffix := range p.suffixes {
+			suffix = fold(suffix)
+			if !strings.HasSuffix(folded, suffix) || len([]rune(folded)) <= len([]rune(suffix)) {
+				continue
+			}
+			runes := []rune(part)
+			stem := string(runes[:len(runes)-p.cut])
+			for i, ending := range p.endings {
+				forms[i] = stem + ending
+			}
+			return forms
+		}
+	}
+
+	for i := range forms {
+		forms[i] = part
+	}
+	return forms
+}
+
+// matchCase capitalizes form like the word it replaces.
+func matchCase(form, word string) string {
+	runes := []rune(form)
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		break
+	}
+	return string(runes)
+}
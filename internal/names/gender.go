@@ -0,0 +1,104 @@
+// Package names holds the rule-based handling of Russian full names.
+package names
+
+import "strings"
+
+// Genders returned by InferGender.
+const (
+	Male   = "male"
+	Female = "female"
+)
+
+// GenderGuess is a gender inferred from name endings. Rule names the
+// ending that decided it, e.g. "patronymic -овна".
+type GenderGuess struct {
+	Gender      string
+	Probability float64
+	Rule        string
+}
+
+type ending struct {
+	suffix string
+	gender string
+}
+
+// Patronymic endings are nearly unambiguous. Longer endings come first so
+// -инична is reported rather than -ична.
+var patronymicEndings = []ending{
+	{"инична", Female}, {"ична", Female}, {"овна", Female}, {"евна", Female},
+	{"ович", Male}, {"евич", Male}, {"ич", Male},
+	{"inichna", Female}, {"ichna", Female}, {"ovna", Female}, {"evna", Female},
+	{"ovich", Male}, {"evich", Male}, {"ich", Male},
+}
+
+// Turkic patronymics end in a separate word: "Алиев Рашид оглы".
+var patronymicParticles = []ending{
+	{"оглы", Male}, {"оглу", Male}, {"улы", Male},
+	{"кызы", Female}, {"гызы", Female},
+	{"ogly", Male}, {"oglu", Male}, {"uly", Male},
+	{"kyzy", Female}, {"gyzy", Female},
+}
+
+// Surname endings are weaker evidence: -ин also ends foreign surnames such
+// as Мартин, so the Latin -in is left out entirely.
+var surnameEndings = []ending{
+	{"ская", Female}, {"цкая", Female}, {"ова", Female}, {"ева", Female}, {"ина", Female}, {"ына", Female},
+	{"ский", Male}, {"цкий", Male}, {"ов", Male}, {"ев", Male}, {"ин", Male}, {"ын", Male},
+	{"tskaya", Female}, {"skaya", Female}, {"ova", Female}, {"eva", Female},
+	{"skiy", Male}, {"skii", Male}, {"sky", Male}, {"ov", Male}, {"ev", Male},
+}
+
+const (
+	patronymicProbability = 0.99
+	surnameProbability    = 0.9
+)
+
+// InferGender decides the gender from the patronymic and, failing that, the
+// surname. It reports false when neither has a known ending.
+func InferGender(patronymic, surname string) (GenderGuess, bool) {
+	if p := fold(patronymic); p != "" {
+		words := strings.Fields(p)
+		last := words[len(words)-1]
+		if len(words) > 1 {
+			if e, ok := match(last, patronymicParticles, true); ok {
+				return GenderGuess{e.gender, patronymicProbability, "patronymic " + e.suffix}, true
+			}
+		}
+		if e, ok := match(words[0], patronymicEndings, false); ok {
+			return GenderGuess{e.gender, patronymicProbability, "patronymic -" + e.suffix}, true
+		}
+	}
+
+	if s := fold(surname); s != "" {
+		words := strings.Fields(s)
+		// Double-barrelled surnames such as Римская-Корсакова share the
+		// ending of the last part.
+		parts := strings.Split(words[len(words)-1], "-")
+		if e, ok := match(parts[len(parts)-1], surnameEndings, false); ok {
+			return GenderGuess{e.gender, surnameProbability, "surname -" + e.suffix}, true
+		}
+	}
+	return GenderGuess{}, false
+}
+
+// match finds the first ending of word, or the whole word when exact is
+// set. A suffix must leave at least two letters of stem.
+func match(word string, endings []ending, exact bool) (ending, bool) {
+	for _, e := range endings {
+		if exact {
+			if word == e.suffix {
+				return e, true
+			}
+			continue
+		}
+		if strings.HasSuffix(word, e.suffix) && len([]rune(word))-len([]rune(e.suffix)) >= 2 {
+			return e, true
+		}
+	}
+	return ending{}, false
+}
+
+// fold lowercases s and replaces ё with е for comparison.
+func fold(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е")
+}
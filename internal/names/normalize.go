@@ -0,0 +1,51 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// particles stay lowercase inside a name, as in "Рашид оглы".
+var particles = map[string]bool{
+	"оглы": true, "оглу": true, "улы": true, "кызы": true, "гызы": true,
+	"ogly": true, "oglu": true, "uly": true, "kyzy": true, "gyzy": true,
+}
+
+// Normalize brings a name part to one canonical spelling: NFC form, no
+// surrounding or repeated whitespace, no spaces around hyphens, and every
+// word and hyphenated part capitalized. ё is kept; Transliterate and the
+// comparisons through fold treat it as е.
+func Normalize(s string) string {
+	s = norm.NFC.String(s)
+	words := strings.Fields(s)
+	s = strings.Join(words, " ")
+	s = strings.ReplaceAll(s, " -", "-")
+	s = strings.ReplaceAll(s, "- ", "-")
+
+	words = strings.Split(s, " ")
+	for i, w := range words {
+		if i > 0 && particles[strings.ToLower(w)] {
+			words[i] = strings.ToLower(w)
+			continue
+		}
+		words[i] = capitalize(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// capitalize uppercases the first letter of each hyphen- or
+// apostrophe-separated part and lowercases the rest: "о'коннор-смит"
+// becomes "О'Коннор-Смит".
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	start := true
+	for i, r := range runes {
+		if start && unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+		}
+		start = r == '-' || r == '\'' || r == '’'
+	}
+	return string(runes)
+}
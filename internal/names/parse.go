@@ -0,0 +1,166 @@
+package names
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Orders of the components in a parsed full name. Without a patronymic
+// the order is reported as "surname name" or "name surname".
+const (
+	OrderSurnameFirst = "surname name patronymic"
+	OrderNameFirst    = "name patronymic surname"
+)
+
+// ParsedName is a full name split into its components. Confidence says how
+// sure the split is and Reason names the rule that decided it.
+type ParsedName struct {
+	Name       string
+	Surname    string
+	Patronymic string
+	Order      string
+	Confidence float64
+	Reason     string
+}
+
+// Confidence of each kind of split. A patronymic ending fixes the order of
+// all three words; surname endings only tell two words apart.
+const (
+	patronymicConfidence    = 0.95
+	ambiguousConfidence     = 0.7
+	surnameEndingConfidence = 0.8
+	defaultOrderConfidence  = 0.5
+	noPatronymicConfidence  = 0.4
+)
+
+// Errors returned by Parse.
+var (
+	ErrTooFewWords  = errors.New("full name must contain at least a name and a surname")
+	ErrTooManyWords = errors.New("full name must contain at most a surname, a name and a patronymic")
+)
+
+// weakSurnameEndings also end common first names such as Ирина or Галина,
+// so they lose to any other surname ending.
+var weakSurnameEndings = map[string]bool{"ин": true, "ина": true, "ын": true, "ына": true}
+
+// Parse splits a full name written as "Иванов Иван Иванович", "Иван
+// Иванович Иванов", "Ivan Ivanov" and the like. The patronymic, when
+// there is one, is found by its ending and fixes the order; otherwise
+// surname endings decide, and Cyrillic names default to the surname first
+// and Latin names to the name first. The components are normalized.
+func Parse(full string) (ParsedName, error) {
+	words := splitWords(Normalize(full))
+	switch {
+	case len(words) < 2:
+		return ParsedName{}, ErrTooFewWords
+	case len(words) > 3:
+		return ParsedName{}, ErrTooManyWords
+	case len(words) == 2:
+		return parseTwo(words[0], words[1]), nil
+	default:
+		return parseThree(words[0], words[1], words[2]), nil
+	}
+}
+
+// splitWords splits s on spaces and joins a Turkic particle to the word
+// before it, so "Рашид оглы" stays one patronymic.
+func splitWords(s string) []string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if len(words) > 0 && particles[strings.ToLower(w)] {
+			words[len(words)-1] += " " + w
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func parseTwo(first, second string) ParsedName {
+	firstScore, secondScore := surnameScore(first), surnameScore(second)
+	switch {
+	case firstScore > secondScore:
+		return surnameFirst(first, second, "", surnameEndingConfidence, "surname ending in first word")
+	case secondScore > firstScore:
+		return nameFirst(first, "", second, surnameEndingConfidence, "surname ending in last word")
+	case isCyrillic(first):
+		return surnameFirst(first, second, "", defaultOrderConfidence, "default order for Cyrillic names")
+	default:
+		return nameFirst(first, "", second, defaultOrderConfidence, "default order for Latin names")
+	}
+}
+
+func parseThree(first, second, third string) ParsedName {
+	thirdIsPatronymic, secondIsPatronymic := isPatronymic(third), isPatronymic(second)
+	switch {
+	case thirdIsPatronymic && !secondIsPatronymic:
+		return surnameFirst(first, second, third, patronymicConfidence, "patronymic ending in last word")
+	case secondIsPatronymic && !thirdIsPatronymic:
+		return nameFirst(first, second, third, patronymicConfidence, "patronymic ending in second word")
+	case thirdIsPatronymic && secondIsPatronymic:
+		// Surnames such as Шостакович end like patronymics; the word that
+		// looks more like a surname goes to the surname.
+		if surnameScore(first) > 0 {
+			return surnameFirst(first, second, third, ambiguousConfidence, "patronymic ending in last word, surname ending in first word")
+		}
+		return nameFirst(first, second, third, ambiguousConfidence, "patronymic endings in second and last words")
+	case surnameScore(first) > surnameScore(third):
+		return surnameFirst(first, second, third, noPatronymicConfidence, "no patronymic ending, surname ending in first word")
+	case surnameScore(third) > surnameScore(first):
+		return nameFirst(first, second, third, noPatronymicConfidence, "no patronymic ending, surname ending in last word")
+	case isCyrillic(first):
+		return surnameFirst(first, second, third, noPatronymicConfidence, "no patronymic ending, default order for Cyrillic names")
+	default:
+		return nameFirst(first, second, third, noPatronymicConfidence, "no patronymic ending, default order for Latin names")
+	}
+}
+
+func surnameFirst(surname, name, patronymic string, confidence float64, reason string) ParsedName {
+	return ParsedName{name, surname, patronymic, order(OrderSurnameFirst, patronymic), confidence, reason}
+}
+
+func nameFirst(name, patronymic, surname string, confidence float64, reason string) ParsedName {
+	return ParsedName{name, surname, patronymic, order(OrderNameFirst, patronymic), confidence, reason}
+}
+
+func order(full, patronymic string) string {
+	if patronymic == "" {
+		return strings.Join(strings.Fields(strings.Replace(full, "patronymic", "", 1)), " ")
+	}
+	return full
+}
+
+func isPatronymic(word string) bool {
+	w := fold(word)
+	if fields := strings.Fields(w); len(fields) > 1 {
+		_, ok := match(fields[len(fields)-1], patronymicParticles, true)
+		return ok
+	}
+	_, ok := match(w, patronymicEndings, false)
+	return ok
+}
+
+// surnameScore is 2 for a typical surname ending, 1 for an ending shared
+// with first names and 0 for none.
+func surnameScore(word string) int {
+	parts := strings.Split(fold(word), "-")
+	e, ok := match(parts[len(parts)-1], surnameEndings, false)
+	switch {
+	case !ok:
+		return 0
+	case weakSurnameEndings[e.suffix]:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			return unicode.Is(unicode.Cyrillic, r)
+		}
+	}
+	return false
+}
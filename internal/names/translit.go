@@ -0,0 +1,48 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// icao maps Cyrillic letters to Latin as in ICAO Doc 9303 and
+// GOST R 52535.1-2006, the scheme of Russian international passports. It
+// maps ё to e, so spellings with ё and е transliterate alike.
+var icao = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	// Ukrainian and Belarusian letters met in Russian documents.
+	'і': "i", 'ї': "i", 'є': "ie", 'ґ': "g", 'ў': "u",
+}
+
+// Transliterate writes s in Latin letters. Other characters, Latin letters
+// included, are kept, so an already Latin name comes back unchanged.
+func Transliterate(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		latin, ok := icao[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		if lower == r || latin == "" {
+			b.WriteString(latin)
+			continue
+		}
+		// A capital next to another capital is part of an uppercase word
+		// and stays fully uppercase (ЩУКА -> SHCHUKA); otherwise only the
+		// first Latin letter is (Щука -> Shchuka).
+		if i > 0 && unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsUpper(runes[i+1]) {
+			b.WriteString(strings.ToUpper(latin))
+			continue
+		}
+		b.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// gapTimeout is how long the feed waits for a missing event ID before
+	// moving past it. IDs are taken when a transaction inserts the event,
+	// so a later ID can commit first; most gaps are rolled back
+	// transactions that never fill.
+	gapTimeout = 5 * time.Second
+	// pollLimit bounds the events read from the table at once.
+	pollLimit = 500
+)
+
+// Feed follows the outbox table in ID order, independent of the relay, and
+// lets any number of readers wait for new events. Every replica runs its
+// own feed, so each sees all events.
+type Feed struct {
+	db  *sql.DB
+	cfg config.StreamConfig
+
+	mu      sync.Mutex
+	buffer  []models.PersonEvent
+	last    int64
+	gap     time.Time
+	changed chan struct{}
+	done    chan struct{}
+	closed  bool
+	clients int
+}
+
+// NewFeed starts the feed at the newest event in the table.
+func NewFeed(ctx context.Context, db *sql.DB, cfg config.StreamConfig) (*Feed, error) {
+	f := &Feed{db: db, cfg: cfg, changed: make(chan struct{}), done: make(chan struct{})}
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM outbox").Scan(&f.last); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Run polls the table until ctx is done.
+func (f *Feed) Run(ctx context.Context) {
+	for {
+		if err := f.poll(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Failed to read outbox events for the stream")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.cfg.PollInterval):
+		}
+	}
+}
+
+// Close wakes every reader and tells it to stop.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.done)
+	}
+}
+
+// Done is closed when the feed is closed.
+func (f *Feed) Done() <-chan struct{} {
+	return f.done
+}
+
+// Changed returns a channel closed when events newer than the current
+// ones arrive. Readers take it before calling Since so none is missed.
+func (f *Feed) Changed() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.changed
+}
+
+// Last is the ID of the newest event readers can see.
+func (f *Feed) Last() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// Since returns the events after id, oldest first and at most pollLimit,
+// and the ID to continue from. Events no longer buffered are read back
+// from the table; events deleted by the retention are skipped.
+func (f *Feed) Since(ctx context.Context, id int64) ([]models.PersonEvent, int64, error) {
+	f.mu.Lock()
+	last := f.last
+	if id >= last {
+		f.mu.Unlock()
+		return nil, id, nil
+	}
+	if len(f.buffer) > 0 && id >= f.buffer[0].ID-1 {
+		var events []models.PersonEvent
+		for _, e := range f.buffer {
+			if e.ID > id {
+				events = append(events, e)
+			}
+			if len(events) == pollLimit {
+				break
+			}
+		}
+		f.mu.Unlock()
+		return events, next(events, last), nil
+	}
+	f.mu.Unlock()
+
+	metrics.Add("stream_replays_total", 1)
+	rows, err := f.db.QueryContext(ctx, `
+		SELECT id, event_type, person_id, payload, created_at
+		FROM outbox
+		WHERE id > $1 AND id <= $2
+		ORDER BY id
+		LIMIT $3`, id, last, pollLimit)
+	if err != nil {
+		return nil, id, err
+	}
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, id, err
+	}
+	return events, next(events, last), nil
+}
+
+// next is the ID after a page of events: the last one, or last when the
+// page is empty because the events were pruned.
+func next(events []models.PersonEvent, last int64) int64 {
+	if len(events) == 0 {
+		return last
+	}
+	return events[len(events)-1].ID
+}
+
+// Join counts a new reader and reports false when MaxClients are already
+// reading. Every successful Join is paired with a Leave.
+func (f *Feed) Join() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clients >= f.cfg.MaxClients {
+		metrics.Add("stream_rejected_total", 1)
+		return false
+	}
+	f.clients++
+	metrics.Add("stream_clients", 1)
+	return true
+}
+
+// Leave undoes Join.
+func (f *Feed) Leave() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clients--
+	metrics.Add("stream_clients", -1)
+}
+
+func (f *Feed) poll(ctx context.Context) error {
+	f.mu.Lock()
+	after := f.last
+	f.mu.Unlock()
+
+	rows, err := f.db.QueryContext(ctx, `
+		SELECT id, event_type, person_id, payload, created_at
+		FROM outbox
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, after, pollLimit)
+	if err != nil {
+		return err
+	}
+	events, err := scanEvents(rows)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	added := 0
+	for _, e := range events {
+		if e.ID != f.last+1 {
+			if f.gap.IsZero() {
+				f.gap = time.Now()
+			}
+			if time.Since(f.gap) < gapTimeout {
+				break
+			}
+		}
+		f.gap = time.Time{}
+		f.buffer = append(f.buffer, e)
+		f.last = e.ID
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+	if n := len(f.buffer) - f.cfg.BufferSize; n > 0 {
+		f.buffer = append([]models.PersonEvent(nil), f.buffer[n:]...)
+	}
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return nil
+}
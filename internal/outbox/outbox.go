@@ -0,0 +1,49 @@
+// Package outbox records person events in the outbox table and relays them
+// to an external sink.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"expvar"
+	"fmt"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+)
+
+var metrics = expvar.NewMap("outbox")
+
+// Execer is satisfied by *sql.Tx, so events are written in the transaction
+// of the change they report.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Recorder writes events into the outbox table. A disabled Recorder writes
+// nothing, so the table does not grow without a relay draining it.
+type Recorder struct {
+	enabled bool
+}
+
+func NewRecorder(enabled bool) *Recorder {
+	return &Recorder{enabled: enabled}
+}
+
+// Record adds an event of eventType about person to the outbox.
+func (r *Recorder) Record(ctx context.Context, tx Execer, eventType string, person *models.Person) error {
+	if !r.enabled {
+		return nil
+	}
+	payload, err := json.Marshal(person)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO outbox (event_type, person_id, payload) VALUES ($1, $2, $3)",
+		eventType, person.ID, payload)
+	if err != nil {
+		return fmt.Errorf("record %s event: %w", eventType, err)
+	}
+	metrics.Add("recorded_total", 1)
+	return nil
+}
@@ -0,0 +1,169 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// pruneInterval is how often published events past the retention are
+// deleted.
+const pruneInterval = time.Hour
+
+// Relay publishes unpublished outbox events to a sink in ID order. Rows
+// are locked with SKIP LOCKED, so replicas running their own relay do not
+// publish the same batch concurrently.
+type Relay struct {
+	db   *sql.DB
+	sink Sink
+	cfg  config.OutboxConfig
+
+	lastPrune time.Time
+}
+
+func NewRelay(db *sql.DB, sink Sink, cfg config.OutboxConfig) *Relay {
+	return &Relay{db: db, sink: sink, cfg: cfg}
+}
+
+// Run relays events until ctx is done. A failed event stops its batch and
+// is retried with exponential backoff; events after it wait, which keeps
+// them in order.
+func (r *Relay) Run(ctx context.Context) {
+	logrus.WithField("sink", r.sink.Name()).Info("Outbox relay started")
+	backoff := r.cfg.RetryBackoff
+	for {
+		n, err := r.relayBatch(ctx)
+		wait := r.cfg.PollInterval
+		switch {
+		case ctx.Err() != nil:
+			logrus.Info("Outbox relay stopped")
+			return
+		case err != nil:
+			logrus.WithError(err).WithField("retry_in", backoff.String()).Warn("Failed to relay outbox events")
+			wait = backoff
+			backoff = min(2*backoff, r.cfg.RetryMaxBackoff)
+		case n == r.cfg.BatchSize:
+			// More events are waiting.
+			backoff = r.cfg.RetryBackoff
+			continue
+		default:
+			backoff = r.cfg.RetryBackoff
+			r.prune(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Outbox relay stopped")
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// relayBatch publishes up to BatchSize events and marks those the sink
+// accepted. It returns the number of events read.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	events, err := pendingEvents(ctx, tx, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var published []int64
+	var publishErr error
+	for _, e := range events {
+		if publishErr = r.sink.Publish(ctx, e); publishErr != nil {
+			metrics.Add("failed_total", 1)
+			logrus.WithError(publishErr).WithFields(logrus.Fields{
+				"event_id": e.ID,
+				"type":     e.Type,
+				"sink":     r.sink.Name(),
+			}).Debug("Failed to publish outbox event")
+			if _, err := tx.ExecContext(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2",
+				publishErr.Error(), e.ID); err != nil {
+				return len(events), err
+			}
+			break
+		}
+		published = append(published, e.ID)
+	}
+
+	if len(published) > 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE outbox SET published_at = now() WHERE id = ANY($1)", pq.Array(published)); err != nil {
+			return len(events), err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		// The sink has the events already; they are published again
+		// after the locks are released.
+		return len(events), err
+	}
+	metrics.Add("published_total", int64(len(published)))
+	return len(events), publishErr
+}
+
+func pendingEvents(ctx context.Context, tx *sql.Tx, limit int) ([]models.PersonEvent, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, event_type, person_id, payload, created_at
+		FROM outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanEvents(rows)
+}
+
+// scanEvents reads rows of id, event_type, person_id, payload and
+// created_at.
+func scanEvents(rows *sql.Rows) ([]models.PersonEvent, error) {
+	defer rows.Close()
+
+	var events []models.PersonEvent
+	for rows.Next() {
+		var e models.PersonEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &e.PersonID, &payload, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			if err := json.Unmarshal(payload, &e.Person); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// prune deletes events published longer than the retention ago, at most
+// once per pruneInterval.
+func (r *Relay) prune(ctx context.Context) {
+	if time.Since(r.lastPrune) < pruneInterval {
+		return
+	}
+	r.lastPrune = time.Now()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM outbox WHERE published_at < now() - $1 * interval '1 second'",
+		r.cfg.Retention.Seconds())
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to delete published outbox events")
+		return
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		logrus.WithField("deleted", n).Debug("Deleted published outbox events")
+	}
+}
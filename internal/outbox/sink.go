@@ -0,0 +1,175 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Sink publishes events. Publish returns nil only once the event is
+// accepted; the relay retries it otherwise.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event models.PersonEvent) error
+	Close() error
+}
+
+// NewSink builds the sink cfg.Sink names.
+func NewSink(cfg config.OutboxConfig) (Sink, error) {
+	switch cfg.Sink {
+	case config.SinkNone:
+		return discardSink{}, nil
+	case config.SinkStdout:
+		return &writerSink{w: os.Stdout}, nil
+	case config.SinkWebhook:
+		return &webhookSink{url: cfg.Webhook.URL, client: &http.Client{Timeout: cfg.Webhook.Timeout}}, nil
+	case config.SinkNATS:
+		return newNATSSink(cfg.NATS)
+	default:
+		return nil, fmt.Errorf("unknown outbox sink %q", cfg.Sink)
+	}
+}
+
+// Fanout publishes each event to every sink in turn. An event is accepted
+// only when all sinks accepted it, so a sink that failed makes the earlier
+// ones see the event again.
+func Fanout(sinks ...Sink) Sink {
+	return fanout(sinks)
+}
+
+type fanout []Sink
+
+func (f fanout) Name() string {
+	names := make([]string, len(f))
+	for i, s := range f {
+		names[i] = s.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f fanout) Publish(ctx context.Context, event models.PersonEvent) error {
+	for _, s := range f {
+		if err := s.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s: %w", s.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (f fanout) Close() error {
+	var errs []error
+	for _, s := range f {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// discardSink accepts every event, so the relay still marks them published
+// and the retention applies when only subscriptions or the stream read
+// them.
+type discardSink struct{}
+
+func (discardSink) Name() string { return config.SinkNone }
+
+func (discardSink) Publish(context.Context, models.PersonEvent) error { return nil }
+
+func (discardSink) Close() error { return nil }
+
+// writerSink writes each event as a line of JSON.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *writerSink) Name() string { return config.SinkStdout }
+
+func (s *writerSink) Publish(_ context.Context, event models.PersonEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(s.w).Encode(event)
+}
+
+func (s *writerSink) Close() error { return nil }
+
+// webhookSink POSTs each event to a fixed URL.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string { return config.SinkWebhook }
+
+func (s *webhookSink) Publish(ctx context.Context, event models.PersonEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error { return nil }
+
+// natsSink publishes to JetStream and waits for the stream's ack.
+type natsSink struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	prefix string
+}
+
+func newNATSSink(cfg config.OutboxNATSConfig) (*natsSink, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("persons-outbox"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open JetStream: %w", err)
+	}
+	return &natsSink{conn: conn, js: js, prefix: cfg.SubjectPrefix}, nil
+}
+
+func (s *natsSink) Name() string { return config.SinkNATS }
+
+func (s *natsSink) Publish(ctx context.Context, event models.PersonEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.js.Publish(ctx, s.prefix+"."+event.Type, body, jetstream.WithMsgID(strconv.FormatInt(event.ID, 10)))
+	return err
+}
+
+func (s *natsSink) Close() error {
+	return s.conn.Drain()
+}
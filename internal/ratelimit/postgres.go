@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PostgresLimiter keeps buckets in the rate_limit_buckets table so that all
+// replicas share them. Each Allow is a single atomic upsert.
+type PostgresLimiter struct {
+	db        *sql.DB
+	retention time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresLimiter deletes buckets untouched for longer than retention,
+// which must exceed the time any configured bucket takes to refill.
+func NewPostgresLimiter(db *sql.DB, retention time.Duration) *PostgresLimiter {
+	return &PostgresLimiter{db: db, retention: retention}
+}
+
+const allowQuery = `
+	INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::float8 - 1, $2::float8 >= 1, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8)
+			- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8) >= 1 THEN 1 ELSE 0 END,
+		allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8) >= 1,
+		updated_at = now()
+	RETURNING tokens, allowed`
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.sweep()
+
+	var tokens float64
+	var allowed bool
+	err := l.db.QueryRowContext(ctx, allowQuery, key, limit.Burst, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(allowed, tokens, limit), nil
+}
+
+func (l *PostgresLimiter) sweep() {
+	l.mu.Lock()
+	if time.Since(l.lastSweep) < sweepInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.lastSweep = time.Now()
+	l.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := l.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1 * interval '1 second'",
+			l.retention.Seconds())
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to delete stale rate limit buckets")
+		}
+	}()
+}
@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Burst tokens at most, refilled at Rate
+// tokens per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerPeriod builds a limit allowing requests per period with the given burst.
+func PerPeriod(requests int, period time.Duration, burst int) Limit {
+	return Limit{Rate: float64(requests) / period.Seconds(), Burst: burst}
+}
+
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Limiter takes one token from the bucket identified by key.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result derives the outcome from the token count left after the request.
+func result(allowed bool, tokens float64, limit Limit) Result {
+	r := Result{Allowed: allowed, Remaining: int(math.Max(0, math.Floor(tokens)))}
+	if !allowed && limit.Rate > 0 {
+		r.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	return r
+}
@@ -0,0 +1,44 @@
+package repository
+
+import "github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+
+// Filter selects persons by exact attribute values; empty fields match
+// everything. Name parts match in either script through the
+// transliteration.
+type Filter struct {
+	Name        string
+	Surname     string
+	Patronymic  string
+	Age         *int
+	Gender      string
+	Nationality string
+}
+
+// Matches reports whether List would select p, for filtering events in
+// memory.
+func (f Filter) Matches(p *models.Person) bool {
+	if p == nil {
+		return false
+	}
+	return nameMatches(f.Name, p.Name, p.NameLatin) &&
+		nameMatches(f.Surname, p.Surname, p.SurnameLatin) &&
+		nameMatches(f.Patronymic, deref(p.Patronymic), p.PatronymicLatin) &&
+		(f.Age == nil || p.Age != nil && *p.Age == *f.Age) &&
+		(f.Gender == "" || deref(p.Gender) == f.Gender) &&
+		(f.Nationality == "" || deref(p.Nationality) == f.Nationality)
+}
+
+func nameMatches(filter, value string, latin *string) bool {
+	if filter == "" || value == filter {
+		return true
+	}
+	_, filterLatin := normalizeName(filter)
+	return latin != nil && *latin == *filterLatin
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/names"
+	"github.com/sirupsen/logrus"
+)
+
+// normalizePerson brings the name parts of p to their canonical spelling
+// and derives the Latin transliterations. It reports false when the name
+// or surname is empty afterwards.
+func normalizePerson(p *models.Person) bool {
+	p.Name, p.NameLatin = normalizeName(p.Name)
+	p.Surname, p.SurnameLatin = normalizeName(p.Surname)
+	p.PatronymicLatin = nil
+	if p.Patronymic != nil {
+		patronymic, latin := normalizeName(*p.Patronymic)
+		if patronymic == "" {
+			p.Patronymic = nil
+		} else {
+			p.Patronymic, p.PatronymicLatin = &patronymic, latin
+		}
+	}
+	return p.Name != "" && p.Surname != ""
+}
+
+func normalizeName(s string) (string, *string) {
+	s = names.Normalize(s)
+	latin := names.Transliterate(s)
+	return s, &latin
+}
+
+// errFullNameConflict rejects requests that send full_name together with
+// the separate name parts.
+var errFullNameConflict = errors.New("use either full_name or name, surname and patronymic")
+
+// personFromRequest takes the name parts from req, splitting full_name when
+// it is set. The parts are not normalized yet.
+func personFromRequest(req models.PersonRequest) (models.Person, error) {
+	if req.FullName == "" {
+		return models.Person{Name: req.Name, Surname: req.Surname, Patronymic: req.Patronymic}, nil
+	}
+	if req.Name != "" || req.Surname != "" || req.Patronymic != nil {
+		return models.Person{}, errFullNameConflict
+	}
+	parsed, err := names.Parse(req.FullName)
+	if err != nil {
+		return models.Person{}, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"order":      parsed.Order,
+		"confidence": parsed.Confidence,
+	}).Debug("Full name parsed")
+	person := models.Person{Name: parsed.Name, Surname: parsed.Surname}
+	if parsed.Patronymic != "" {
+		person.Patronymic = &parsed.Patronymic
+	}
+	return person, nil
+}
+
+// merge stores a new prediction and its enrichment details in dst. A value
+// that is already set is kept when onlyMissing is set or there is no new
+// prediction; an empty one at least gets the reason it is still missing.
+// It reports whether anything was written.
+func merge[T any](dst **T, details **models.AttributeEnrichment, prediction *T, fresh *models.AttributeEnrichment, onlyMissing bool) bool {
+	if *dst != nil && (onlyMissing || prediction == nil) {
+		return false
+	}
+	if fresh == nil {
+		return false
+	}
+	if prediction != nil {
+		*dst = prediction
+	}
+	*details = fresh
+	return true
+}
@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/outbox"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/service"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// personColumns lists the persons columns in the order scanPerson reads
+// them.
+const personColumns = "id, name, surname, patronymic, age, gender, nationality, name_latin, surname_latin, patronymic_latin, enrichment"
+
+const insertPerson = `
+	INSERT INTO persons (name, surname, patronymic, age, gender, nationality, name_latin, surname_latin, patronymic_latin, enrichment)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	RETURNING id`
+
+// Persons stores persons for the REST and gRPC APIs. New persons are
+// validated, normalized and enriched here, and every change is recorded in
+// the outbox in the same transaction. A missing person is reported as
+// sql.ErrNoRows.
+type Persons struct {
+	db     *sql.DB
+	enrich *service.EnrichmentService
+	events *outbox.Recorder
+}
+
+func NewPersons(db *sql.DB, enrich *service.EnrichmentService, events *outbox.Recorder) *Persons {
+	return &Persons{db: db, enrich: enrich, events: events}
+}
+
+// ValidationError rejects a request before anything is stored. Its
+// message is meant for the client.
+type ValidationError struct {
+	msg string
+}
+
+func (e *ValidationError) Error() string {
+	return e.msg
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &ValidationError{msg: fmt.Sprintf(format, args...)}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPerson(row rowScanner, p *models.Person) error {
+	return row.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic, &p.Age, &p.Gender, &p.Nationality,
+		&p.NameLatin, &p.SurnameLatin, &p.PatronymicLatin, &p.Enrichment)
+}
+
+func scanPersons(rows *sql.Rows) ([]models.Person, error) {
+	defer rows.Close()
+
+	var persons []models.Person
+	for rows.Next() {
+		var p models.Person
+		if err := scanPerson(rows, &p); err != nil {
+			return nil, err
+		}
+		persons = append(persons, p)
+	}
+	return persons, rows.Err()
+}
+
+// List returns the persons matching f in id order.
+func (r *Persons) List(ctx context.Context, f Filter, limit, offset int) ([]models.Person, error) {
+	query := "SELECT " + personColumns + " FROM persons WHERE 1=1"
+	var args []interface{}
+	argCount := 1
+
+	for _, part := range []struct {
+		column, value string
+	}{
+		{"name", f.Name},
+		{"surname", f.Surname},
+		{"patronymic", f.Patronymic},
+	} {
+		if part.value == "" {
+			continue
+		}
+		// Either script matches through the transliteration; rows written
+		// before it existed only match verbatim.
+		_, latin := normalizeName(part.value)
+		query += " AND (" + part.column + "_latin = $" + strconv.Itoa(argCount) + " OR " + part.column + " = $" + strconv.Itoa(argCount+1) + ")"
+		args = append(args, *latin, part.value)
+		argCount += 2
+	}
+	if f.Age != nil {
+		query += " AND age = $" + strconv.Itoa(argCount)
+		args = append(args, *f.Age)
+		argCount++
+	}
+	if f.Gender != "" {
+		query += " AND gender = $" + strconv.Itoa(argCount)
+		args = append(args, f.Gender)
+		argCount++
+	}
+	if f.Nationality != "" {
+		query += " AND nationality = $" + strconv.Itoa(argCount)
+		args = append(args, f.Nationality)
+		argCount++
+	}
+
+	query += " ORDER BY id LIMIT $" + strconv.Itoa(argCount) + " OFFSET $" + strconv.Itoa(argCount+1)
+	args = append(args, limit, offset)
+
+	logrus.WithFields(logrus.Fields{
+		"query": query,
+		"args":  args,
+	}).Debug("Executing database query for persons")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPersons(rows)
+}
+
+// Get returns the person with the given id.
+func (r *Persons) Get(ctx context.Context, id int) (models.Person, error) {
+	var person models.Person
+	err := scanPerson(r.db.QueryRowContext(ctx, "SELECT "+personColumns+" FROM persons WHERE id = $1", id), &person)
+	return person, err
+}
+
+// Create validates and enriches a new person and stores it. A failed
+// enrichment only leaves the predictions empty.
+func (r *Persons) Create(ctx context.Context, req models.PersonRequest) (models.Person, error) {
+	person, err := personFromRequest(req)
+	if err != nil {
+		return models.Person{}, invalid("Invalid full_name: %v", err)
+	}
+	if !normalizePerson(&person) {
+		return models.Person{}, invalid("Name and surname must not be empty")
+	}
+
+	var countryHint string
+	if req.CountryID != nil {
+		countryHint = *req.CountryID
+	}
+
+	logrus.WithField("name", person.Name).Debug("Starting person enrichment")
+	if err := r.enrich.EnrichPerson(ctx, &person, countryHint); err != nil {
+		logrus.WithError(err).Warn("Failed to enrich person data")
+	}
+
+	logrus.WithField("person", person).Debug("Inserting person into database")
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, insertPerson, person.Name, person.Surname, person.Patronymic,
+			person.Age, person.Gender, person.Nationality, person.NameLatin, person.SurnameLatin, person.PatronymicLatin,
+			person.Enrichment).Scan(&person.ID)
+		if err != nil {
+			return err
+		}
+		return r.events.Record(ctx, tx, models.EventPersonCreated, &person)
+	})
+	return person, err
+}
+
+// CreateMany validates all persons, enriches their distinct names together
+// and stores them in one transaction.
+func (r *Persons) CreateMany(ctx context.Context, reqs []models.PersonRequest) ([]models.Person, error) {
+	persons := make([]models.Person, len(reqs))
+	enrichReqs := make([]service.EnrichRequest, len(reqs))
+	for i, req := range reqs {
+		person, err := personFromRequest(req)
+		if err != nil {
+			return nil, invalid("Person %d: invalid full_name: %v", i, err)
+		}
+		persons[i] = person
+		if !normalizePerson(&persons[i]) {
+			return nil, invalid("Person %d: name and surname must not be empty", i)
+		}
+		enrichReqs[i].Person = &persons[i]
+		if req.CountryID != nil {
+			enrichReqs[i].CountryHint = *req.CountryID
+		}
+	}
+
+	logrus.WithField("count", len(persons)).Debug("Starting bulk enrichment")
+	if err := r.enrich.EnrichPersons(ctx, enrichReqs); err != nil {
+		logrus.WithError(err).Warn("Failed to enrich person data")
+	}
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, insertPerson)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for i := range persons {
+			p := &persons[i]
+			if err := stmt.QueryRowContext(ctx, p.Name, p.Surname, p.Patronymic,
+				p.Age, p.Gender, p.Nationality, p.NameLatin, p.SurnameLatin, p.PatronymicLatin,
+				p.Enrichment).Scan(&p.ID); err != nil {
+				return err
+			}
+			if err := r.events.Record(ctx, tx, models.EventPersonCreated, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return persons, nil
+}
+
+// Update replaces the person with the given id. The enrichment details
+// are not client-writable; the stored ones are returned as they are.
+func (r *Persons) Update(ctx context.Context, id int, person models.Person) (models.Person, error) {
+	person.ID = id
+	if !normalizePerson(&person) {
+		return models.Person{}, invalid("Name and surname must not be empty")
+	}
+
+	query := `
+		UPDATE persons
+		SET name = $1, surname = $2, patronymic = $3, age = $4, gender = $5, nationality = $6,
+		    name_latin = $7, surname_latin = $8, patronymic_latin = $9
+		WHERE id = $10
+		RETURNING enrichment`
+
+	person.Enrichment = nil
+	logrus.WithField("id", id).Debug("Updating person in database")
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, person.Name, person.Surname, person.Patronymic,
+			person.Age, person.Gender, person.Nationality, person.NameLatin, person.SurnameLatin, person.PatronymicLatin,
+			person.ID).Scan(&person.Enrichment)
+		if err != nil {
+			return err
+		}
+		return r.events.Record(ctx, tx, models.EventPersonUpdated, &person)
+	})
+	return person, err
+}
+
+// Patch updates the fields set in patch. An empty patronymic clears it.
+func (r *Persons) Patch(ctx context.Context, id int, patch models.PersonPatch) (models.Person, error) {
+	query := "UPDATE persons SET "
+	var args []interface{}
+	argCount := 1
+
+	for _, part := range []struct {
+		column string
+		value  *string
+	}{
+		{"name", patch.Name},
+		{"surname", patch.Surname},
+		{"patronymic", patch.Patronymic},
+	} {
+		if part.value == nil {
+			continue
+		}
+		value, latin := normalizeName(*part.value)
+		var stored, storedLatin interface{} = value, *latin
+		if value == "" {
+			if part.column != "patronymic" {
+				return models.Person{}, invalid("Name and surname must not be empty")
+			}
+			stored, storedLatin = nil, nil
+		}
+		query += part.column + " = $" + strconv.Itoa(argCount) + ", " + part.column + "_latin = $" + strconv.Itoa(argCount+1) + ", "
+		args = append(args, stored, storedLatin)
+		argCount += 2
+	}
+	if patch.Age != nil {
+		query += "age = $" + strconv.Itoa(argCount) + ", "
+		args = append(args, *patch.Age)
+		argCount++
+	}
+	if patch.Gender != nil {
+		query += "gender = $" + strconv.Itoa(argCount) + ", "
+		args = append(args, *patch.Gender)
+		argCount++
+	}
+	if patch.Nationality != nil {
+		query += "nationality = $" + strconv.Itoa(argCount) + ", "
+		args = append(args, *patch.Nationality)
+		argCount++
+	}
+
+	if argCount == 1 {
+		return models.Person{}, invalid("No fields to update")
+	}
+
+	query = query[:len(query)-2]
+	query += " WHERE id = $" + strconv.Itoa(argCount) + " RETURNING " + personColumns
+	args = append(args, id)
+
+	logrus.WithField("id", id).Debug("Updating person in database")
+	var updated models.Person
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := scanPerson(tx.QueryRowContext(ctx, query, args...), &updated); err != nil {
+			return err
+		}
+		return r.events.Record(ctx, tx, models.EventPersonUpdated, &updated)
+	})
+	return updated, err
+}
+
+// Delete removes the person with the given id and returns its last state.
+func (r *Persons) Delete(ctx context.Context, id int) (models.Person, error) {
+	logrus.WithField("id", id).Debug("Preparing to delete person")
+	var deleted models.Person
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		err := scanPerson(tx.QueryRowContext(ctx, "DELETE FROM persons WHERE id = $1 RETURNING "+personColumns, id), &deleted)
+		if err != nil {
+			return err
+		}
+		return r.events.Record(ctx, tx, models.EventPersonDeleted, &deleted)
+	})
+	return deleted, err
+}
+
+// Enrich predicts the attributes of one stored person again, as a
+// re-enrichment job would, and returns the result.
+func (r *Persons) Enrich(ctx context.Context, id int, onlyMissing bool) (models.Person, error) {
+	person, err := r.Get(ctx, id)
+	if err != nil {
+		return models.Person{}, err
+	}
+	persons := []models.Person{person}
+	if _, err := r.Reenrich(ctx, persons, onlyMissing); err != nil {
+		return models.Person{}, err
+	}
+	return persons[0], nil
+}
+
+// ReenrichPage returns up to limit persons with an id above after that a
+// re-enrichment job selected by req covers.
+func (r *Persons) ReenrichPage(ctx context.Context, req models.ReEnrichRequest, after, limit int) ([]models.Person, error) {
+	query := "SELECT " + personColumns + " FROM persons WHERE id > $1"
+	args := []interface{}{after}
+	if req.OnlyMissing {
+		query += " AND (age IS NULL OR gender IS NULL OR nationality IS NULL)"
+	}
+	if len(req.IDs) > 0 {
+		query += " AND id = ANY($2)"
+		args = append(args, pq.Array(req.IDs))
+	}
+	query += " ORDER BY id LIMIT " + strconv.Itoa(limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPersons(rows)
+}
+
+// Reenrich enriches fresh copies of persons in one batch and writes the
+// predictions back, updating persons in place. With onlyMissing existing
+// values are kept. It returns how many persons changed.
+func (r *Persons) Reenrich(ctx context.Context, persons []models.Person, onlyMissing bool) (int, error) {
+	fresh := make([]models.Person, len(persons))
+	reqs := make([]service.EnrichRequest, len(persons))
+	for i, p := range persons {
+		fresh[i] = models.Person{ID: p.ID, Name: p.Name, Surname: p.Surname, Patronymic: p.Patronymic}
+		reqs[i].Person = &fresh[i]
+	}
+	if err := r.enrich.EnrichPersons(ctx, reqs); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range persons {
+			p := &persons[i]
+			details := p.Enrichment
+			if details == nil {
+				details = &models.Enrichment{}
+			}
+			fe := fresh[i].Enrichment
+			if fe == nil {
+				fe = &models.Enrichment{}
+			}
+			changed := merge(&p.Age, &details.Age, fresh[i].Age, fe.Age, onlyMissing)
+			changed = merge(&p.Gender, &details.Gender, fresh[i].Gender, fe.Gender, onlyMissing) || changed
+			changed = merge(&p.Nationality, &details.Nationality, fresh[i].Nationality, fe.Nationality, onlyMissing) || changed
+			if !changed {
+				continue
+			}
+			p.Enrichment = details
+			_, err := tx.ExecContext(ctx,
+				"UPDATE persons SET age = $1, gender = $2, nationality = $3, enrichment = $4 WHERE id = $5",
+				p.Age, p.Gender, p.Nationality, p.Enrichment, p.ID)
+			if err != nil {
+				return err
+			}
+			if err := r.events.Record(ctx, tx, models.EventPersonEnriched, p); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// inTx runs fn in a transaction and commits it if fn succeeds, so outbox
+// events are stored together with the change they report.
+func (r *Persons) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
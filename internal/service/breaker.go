@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// metrics is served by the /metrics endpoint.
+var metrics = expvar.NewMap("enrichment")
+
+type breakerState string
+
+const (
+	stateClosed   breakerState = "closed"
+	stateOpen     breakerState = "open"
+	stateHalfOpen breakerState = "half_open"
+)
+
+// breaker skips a provider after threshold consecutive failures. Once
+// openTimeout has passed it lets a single probe through: success closes the
+// circuit again, failure reopens it.
+type breaker struct {
+	provider    string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	gauge    *expvar.String
+}
+
+func newBreaker(provider string, threshold int, openTimeout time.Duration) *breaker {
+	b := &breaker{
+		provider:    provider,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		state:       stateClosed,
+		gauge:       new(expvar.String),
+	}
+	b.gauge.Set(string(stateClosed))
+	metrics.Set(provider+"_circuit_state", b.gauge)
+	return b
+}
+
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.transition(stateHalfOpen)
+		b.probing = true
+		return nil
+	case stateHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		if b.state != stateClosed {
+			b.transition(stateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || (b.state == stateClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.transition(stateOpen)
+		metrics.Add(b.provider+"_circuit_opens_total", 1)
+	}
+}
+
+// release gives up a probe slot without judging the provider, e.g. when the
+// caller's context was cancelled mid-request.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) transition(to breakerState) {
+	entry := logrus.WithFields(logrus.Fields{
+		"provider": b.provider,
+		"from":     b.state,
+		"to":       to,
+		"failures": b.failures,
+	})
+	if to == stateOpen {
+		entry.Warn("Enrichment circuit breaker state changed")
+	} else {
+		entry.Info("Enrichment circuit breaker state changed")
+	}
+	b.state = to
+	b.gauge.Set(string(to))
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheSourceName is recorded as the provider of values from the cache.
+const cacheSourceName = "cache"
+
+// cacheSource remembers the predictions other links of a chain produced, so
+// it can answer while they are unavailable. Predictions are kept per name
+// and country hint, plus the latest one per name for lookups whose hint
+// differs, and expire after ttl.
+type cacheSource[T any] struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry[T]
+}
+
+type cacheEntry[T any] struct {
+	prediction T
+	expires    time.Time
+}
+
+func newCacheSource[T any](ttl time.Duration, maxEntries int) *cacheSource[T] {
+	return &cacheSource[T]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry[T]),
+	}
+}
+
+func cacheKey(name, countryID string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "|" + countryID
+}
+
+func (c *cacheSource[T]) name() string { return cacheSourceName }
+
+func (c *cacheSource[T]) lookup(_ context.Context, names []string, countryID string) (map[string]T, map[string]error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	predictions := make(map[string]T, len(names))
+	for _, name := range names {
+		for _, key := range []string{cacheKey(name, countryID), cacheKey(name, "")} {
+			e, ok := c.entries[key]
+			if !ok {
+				continue
+			}
+			if now.After(e.expires) {
+				delete(c.entries, key)
+				continue
+			}
+			predictions[name] = e.prediction
+			break
+		}
+	}
+	return predictions, nil
+}
+
+func (c *cacheSource[T]) store(name, countryID string, prediction T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry[T]{prediction: prediction, expires: time.Now().Add(c.ttl)}
+	for _, key := range []string{cacheKey(name, countryID), cacheKey(name, "")} {
+		if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+			c.evictLocked()
+		}
+		c.entries[key] = entry
+	}
+}
+
+// evictLocked drops expired entries, or an arbitrary one when none has
+// expired yet.
+func (c *cacheSource[T]) evictLocked() {
+	now := time.Now()
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+)
+
+// rulesSourceName is recorded as the provider of rule-based predictions.
+const rulesSourceName = "rules"
+
+// assess checks a prediction from source against t. probability and count
+// are nil when the source does not report them, as agify does for the
+// probability, and are not checked then. A prediction below the thresholds
+// is rejected, or only marked when low-confidence values are kept.
+func (s *EnrichmentService) assess(source string, t config.ThresholdConfig, value interface{}, probability *float64, count *int) *models.AttributeEnrichment {
+	e := &models.AttributeEnrichment{
+		Status:      models.EnrichmentAccepted,
+		Provider:    source,
+		Value:       value,
+		Probability: probability,
+		Count:       count,
+	}
+
+	var reasons []string
+	if probability != nil && *probability < t.MinProbability {
+		reasons = append(reasons, fmt.Sprintf("probability %.2f is below %.2f", *probability, t.MinProbability))
+	}
+	if count != nil && *count < t.MinCount {
+		reasons = append(reasons, fmt.Sprintf("sample count %d is below %d", *count, t.MinCount))
+	}
+	if len(reasons) == 0 {
+		return e
+	}
+
+	metrics.Add(source+"_low_confidence_total", 1)
+	e.Reason = strings.Join(reasons, ", ")
+	e.Status = models.EnrichmentRejected
+	if s.markLowConfidence {
+		e.Status = models.EnrichmentLowConfidence
+	}
+	return e
+}
+
+// unavailable records that none of sources had a prediction.
+func unavailable(sources string, err error) *models.AttributeEnrichment {
+	return &models.AttributeEnrichment{
+		Status:   models.EnrichmentUnavailable,
+		Provider: sources,
+		Reason:   err.Error(),
+	}
+}
+
+// enrichmentOf returns the enrichment details of person, creating them on
+// first use.
+func enrichmentOf(person *models.Person) *models.Enrichment {
+	if person.Enrichment == nil {
+		person.Enrichment = &models.Enrichment{}
+	}
+	return person.Enrichment
+}
@@ -1,148 +1,393 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"context"
+	"errors"
+	"sync"
 
-	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/db"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
 	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/names"
 	"github.com/sirupsen/logrus"
 )
 
-type EnrichmentService struct{}
+// ErrDraining is returned for enrichment requested after Drain was called.
+var ErrDraining = errors.New("enrichment service is shutting down")
 
-func (s *EnrichmentService) EnrichPerson(person *models.Person) error {
-	logrus.WithField("name", person.Name).Info("Starting enrichment process for person")
+type EnrichmentService struct {
+	age         *provider
+	gender      *provider
+	nationality *provider
+	// The chains decide in which order the APIs, the cache and the offline
+	// dataset are asked for each attribute.
+	ageChain         chain[agePrediction]
+	genderChain      chain[genderPrediction]
+	nationalityChain chain[nationalityPrediction]
+	// dataset is nil unless a chain uses the offline dataset.
+	dataset *Dataset
+	// countryHints runs the nationality lookup first and passes the top
+	// country to agify and genderize as country_id.
+	countryHints bool
+	// latinNames sends the Latin transliteration of the first name to the
+	// providers instead of the original spelling.
+	latinNames bool
+	// genderRules tries the Russian patronymic and surname rules before
+	// the gender chain.
+	genderRules bool
+	thresholds  config.ThresholdsConfig
+	// markLowConfidence keeps predictions below the thresholds instead of
+	// leaving the attribute empty.
+	markLowConfidence bool
 
-	ageAPI := db.GetEnv("AGE_API_URL", "https://api.agify.io")
-	if age, err := getAge(person.Name, ageAPI); err == nil {
-		person.Age = &age
-		logrus.WithField("name", person.Name).Debug("Successfully enriched with age")
-	} else {
-		logrus.WithFields(logrus.Fields{
-			"name":  person.Name,
-			"error": err,
-		}).Warn("Failed to enrich age")
+	// mu guards draining, so no enrichment is added to inflight once Drain
+	// has started waiting for it.
+	mu       sync.Mutex
+	draining bool
+	inflight sync.WaitGroup
+}
+
+func NewEnrichmentService(cfg config.EnrichmentConfig) (*EnrichmentService, error) {
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &EnrichmentService{
+		age:               newProvider("agify", cfg.AgeAPIURL, client, cfg),
+		gender:            newProvider("genderize", cfg.GenderAPIURL, client, cfg),
+		nationality:       newProvider("nationalize", cfg.NationalityAPIURL, client, cfg),
+		countryHints:      cfg.CountryHints,
+		latinNames:        cfg.NameScript == "latin",
+		genderRules:       cfg.GenderRules,
+		thresholds:        cfg.Thresholds,
+		markLowConfidence: cfg.LowConfidence == "mark",
+	}
+	if usesOffline(cfg) {
+		if s.dataset, err = LoadDataset(cfg.Offline.File); err != nil {
+			return nil, err
+		}
 	}
 
-	genderAPI := db.GetEnv("GENDER_API_URL", "https://api.genderize.io")
-	if gender, err := getGender(person.Name, genderAPI); err == nil {
-		person.Gender = &gender
-		logrus.WithField("name", person.Name).Debug("Successfully enriched with gender")
-	} else {
+	s.ageChain = newChain(cfg, cfg.Chains.Age, apiSource[agePrediction]{s.age}, s.dataset, offlineAge,
+		func(p agePrediction) bool { return p.Age != nil && *p.Age > 0 })
+	s.genderChain = newChain(cfg, cfg.Chains.Gender, apiSource[genderPrediction]{s.gender}, s.dataset, offlineGender,
+		func(p genderPrediction) bool { return p.Gender != nil && *p.Gender != "" })
+	s.nationalityChain = newChain(cfg, cfg.Chains.Nationality, apiSource[nationalityPrediction]{s.nationality}, s.dataset, offlineNationality,
+		func(p nationalityPrediction) bool { return len(p.Country) > 0 })
+
+	for _, c := range []struct{ attribute, sources string }{
+		{"age", s.ageChain.names()},
+		{"gender", s.genderChain.names()},
+		{"nationality", s.nationalityChain.names()},
+	} {
 		logrus.WithFields(logrus.Fields{
-			"name":  person.Name,
-			"error": err,
-		}).Warn("Failed to enrich gender")
+			"attribute": c.attribute,
+			"chain":     c.sources,
+		}).Info("Enrichment provider chain configured")
 	}
+	return s, nil
+}
 
-	nationalityAPI := db.GetEnv("NATIONALITY_API_URL", "https://api.nationalize.io")
-	if nationality, err := getNationality(person.Name, nationalityAPI); err == nil {
-		person.Nationality = &nationality
-		logrus.WithField("name", person.Name).Debug("Successfully enriched with nationality")
-	} else {
-		logrus.WithFields(logrus.Fields{
-			"name":  person.Name,
-			"error": err,
-		}).Warn("Failed to enrich nationality")
+func usesOffline(cfg config.EnrichmentConfig) bool {
+	for _, links := range [][]config.ChainLinkConfig{cfg.Chains.Age, cfg.Chains.Gender, cfg.Chains.Nationality} {
+		for _, l := range cfg.ResolvedChain(links) {
+			if l.Source == config.SourceOffline {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	logrus.WithField("name", person.Name).Info("Completed enrichment process for person")
-	return nil
+// newChain builds the chain of one attribute from its configured links.
+func newChain[T any](cfg config.EnrichmentConfig, links []config.ChainLinkConfig, api source[T], dataset *Dataset, fromDataset func(datasetEntry) T, usable func(T) bool) chain[T] {
+	c := chain[T]{usable: usable}
+	for _, l := range cfg.ResolvedChain(links) {
+		next := link[T]{timeout: l.Timeout}
+		switch l.Source {
+		case config.SourceAPI:
+			next.source = api
+			next.cacheable = true
+		case config.SourceCache:
+			c.cache = newCacheSource[T](cfg.Cache.TTL, cfg.Cache.MaxEntries)
+			next.source = c.cache
+		case config.SourceOffline:
+			next.source = offlineSource[T]{dataset, fromDataset}
+		}
+		c.links = append(c.links, next)
+	}
+	return c
 }
 
-func getAge(name, apiURL string) (int, error) {
-	logrus.WithField("name", name).Debug("Fetching age")
-	resp, err := http.Get(apiURL + "/?name=" + name)
-	if err != nil {
-		return 0, err
+func (s *EnrichmentService) providers() []*provider {
+	return []*provider{s.age, s.gender, s.nationality}
+}
+
+// EnrichRequest is a person to enrich together with an optional ISO 3166-1
+// alpha-2 country hint that narrows the age and gender predictions.
+type EnrichRequest struct {
+	Person      *models.Person
+	CountryHint string
+}
+
+// EnrichPerson fills age, gender and nationality of a single person. Without
+// a countryHint the known or freshly predicted nationality is used as the
+// hint when country hints are enabled.
+func (s *EnrichmentService) EnrichPerson(ctx context.Context, person *models.Person, countryHint string) error {
+	return s.EnrichPersons(ctx, []EnrichRequest{{Person: person, CountryHint: countryHint}})
+}
+
+// EnrichPersons enriches many persons with as few upstream calls as
+// possible: every distinct name is looked up once, in multi-name requests of
+// up to maxBatchNames names, and the results are fanned back out.
+func (s *EnrichmentService) EnrichPersons(ctx context.Context, reqs []EnrichRequest) error {
+	if !s.begin() {
+		return ErrDraining
 	}
-	defer resp.Body.Close()
+	defer s.inflight.Done()
+
+	logrus.WithField("persons", len(reqs)).Info("Starting enrichment process")
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("API returned status: %d", resp.StatusCode)
+	if s.countryHints {
+		s.enrichNationality(ctx, reqs)
 	}
 
-	var result struct {
-		Age int `json:"age"`
+	// agify and genderize take a single country_id per request, so names
+	// are batched per country.
+	byCountry := make(map[string][]EnrichRequest)
+	for _, r := range reqs {
+		country := r.CountryHint
+		if country == "" && s.countryHints && r.Person.Nationality != nil {
+			country = *r.Person.Nationality
+		}
+		byCountry[country] = append(byCountry[country], r)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, err
+	for country, group := range byCountry {
+		s.enrichAge(ctx, group, country)
+		s.enrichGender(ctx, group, country)
 	}
 
-	if result.Age == 0 {
-		return 0, fmt.Errorf("no age prediction available")
+	if !s.countryHints {
+		s.enrichNationality(ctx, reqs)
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"name": name,
-		"age":  result.Age,
-	}).Debug("Age successfully retrieved")
-	return result.Age, nil
+	logrus.WithField("persons", len(reqs)).Info("Completed enrichment process")
+	return nil
 }
 
-func getGender(name, apiURL string) (string, error) {
-	logrus.WithField("name", name).Debug("Fetching gender")
-	resp, err := http.Get(apiURL + "/?name=" + name)
-	if err != nil {
-		return "", err
+func (s *EnrichmentService) enrichAge(ctx context.Context, reqs []EnrichRequest, countryID string) {
+	answers, errs := s.ageChain.lookup(ctx, s.distinctNames(reqs), countryID)
+	for _, r := range reqs {
+		name := s.lookupName(r.Person)
+		a, ok := answers[name]
+		if !ok {
+			enrichmentOf(r.Person).Age = unavailable(s.ageChain.names(), errs[name])
+			logrus.WithFields(logrus.Fields{
+				"name":  name,
+				"error": errs[name],
+			}).Warn("Failed to enrich age")
+			continue
+		}
+
+		p := a.prediction
+		e := s.assess(a.source, s.thresholds.Age, *p.Age, nil, p.Count)
+		enrichmentOf(r.Person).Age = e
+		if e.Status == models.EnrichmentRejected {
+			logRejected(name, "age", e)
+			continue
+		}
+		age := *p.Age
+		r.Person.Age = &age
+		logrus.WithFields(logrus.Fields{
+			"name":   name,
+			"source": a.source,
+		}).Debug("Successfully enriched with age")
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status: %d", resp.StatusCode)
+func (s *EnrichmentService) enrichGender(ctx context.Context, reqs []EnrichRequest, countryID string) {
+	if s.genderRules {
+		reqs = s.genderByRules(reqs)
 	}
+	answers, errs := s.genderChain.lookup(ctx, s.distinctNames(reqs), countryID)
+	for _, r := range reqs {
+		name := s.lookupName(r.Person)
+		a, ok := answers[name]
+		if !ok {
+			enrichmentOf(r.Person).Gender = unavailable(s.genderChain.names(), errs[name])
+			logrus.WithFields(logrus.Fields{
+				"name":  name,
+				"error": errs[name],
+			}).Warn("Failed to enrich gender")
+			continue
+		}
 
-	var result struct {
-		Gender      string  `json:"gender"`
-		Probability float64 `json:"probability"`
+		p := a.prediction
+		e := s.assess(a.source, s.thresholds.Gender, *p.Gender, p.Probability, p.Count)
+		enrichmentOf(r.Person).Gender = e
+		if e.Status == models.EnrichmentRejected {
+			logRejected(name, "gender", e)
+			continue
+		}
+		gender := *p.Gender
+		r.Person.Gender = &gender
+		logrus.WithFields(logrus.Fields{
+			"name":   name,
+			"source": a.source,
+		}).Debug("Successfully enriched with gender")
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+}
+
+func (s *EnrichmentService) enrichNationality(ctx context.Context, reqs []EnrichRequest) {
+	answers, errs := s.nationalityChain.lookup(ctx, s.distinctNames(reqs), "")
+	for _, r := range reqs {
+		name := s.lookupName(r.Person)
+		a, ok := answers[name]
+		if !ok {
+			enrichmentOf(r.Person).Nationality = unavailable(s.nationalityChain.names(), errs[name])
+			logrus.WithFields(logrus.Fields{
+				"name":  name,
+				"error": errs[name],
+			}).Warn("Failed to enrich nationality")
+			continue
+		}
+
+		top := a.prediction.Country[0]
+		e := s.assess(a.source, s.thresholds.Nationality, top.CountryID, top.Probability, a.prediction.Count)
+		enrichmentOf(r.Person).Nationality = e
+		if e.Status == models.EnrichmentRejected {
+			logRejected(name, "nationality", e)
+			continue
+		}
+		nationality := top.CountryID
+		r.Person.Nationality = &nationality
+		logrus.WithFields(logrus.Fields{
+			"name":   name,
+			"source": a.source,
+		}).Debug("Successfully enriched with nationality")
 	}
+}
 
-	if result.Gender == "" {
-		return "", fmt.Errorf("no gender prediction available")
+// genderByRules sets the gender of every person whose patronymic or surname
+// gives it away and returns the others, which still need a lookup.
+func (s *EnrichmentService) genderByRules(reqs []EnrichRequest) []EnrichRequest {
+	var rest []EnrichRequest
+	for _, r := range reqs {
+		var patronymic string
+		if r.Person.Patronymic != nil {
+			patronymic = *r.Person.Patronymic
+		}
+		guess, ok := names.InferGender(patronymic, r.Person.Surname)
+		if !ok {
+			rest = append(rest, r)
+			continue
+		}
+
+		e := s.assess(rulesSourceName, s.thresholds.Gender, guess.Gender, &guess.Probability, nil)
+		e.Rule = guess.Rule
+		if e.Status == models.EnrichmentRejected {
+			logrus.WithFields(logrus.Fields{
+				"name":   r.Person.Name,
+				"rule":   guess.Rule,
+				"reason": e.Reason,
+			}).Debug("Rule-based gender below confidence thresholds, asking providers")
+			rest = append(rest, r)
+			continue
+		}
+		enrichmentOf(r.Person).Gender = e
+		gender := guess.Gender
+		r.Person.Gender = &gender
+		logrus.WithFields(logrus.Fields{
+			"name": r.Person.Name,
+			"rule": guess.Rule,
+		}).Debug("Gender inferred from name endings")
 	}
+	return rest
+}
 
+func logRejected(name, attribute string, e *models.AttributeEnrichment) {
 	logrus.WithFields(logrus.Fields{
-		"name":   name,
-		"gender": result.Gender,
-	}).Debug("Gender successfully retrieved")
-	return result.Gender, nil
+		"name":      name,
+		"attribute": attribute,
+		"value":     e.Value,
+		"reason":    e.Reason,
+	}).Info("Prediction below confidence thresholds, leaving it empty")
 }
 
-func getNationality(name, apiURL string) (string, error) {
-	logrus.WithField("name", name).Debug("Fetching nationality")
-	resp, err := http.Get(apiURL + "/?name=" + name)
-	if err != nil {
-		return "", err
+// CheckProviders probes every enrichment API concurrently.
+func (s *EnrichmentService) CheckProviders(ctx context.Context) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error)
+	for _, p := range s.providers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.check(ctx)
+			mu.Lock()
+			results[p.name] = err
+			mu.Unlock()
+		}()
 	}
-	defer resp.Body.Close()
+	wg.Wait()
+	return results
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status: %d", resp.StatusCode)
+// Quotas reports the last known request allowance of every provider.
+func (s *EnrichmentService) Quotas() []models.ProviderQuota {
+	quotas := make([]models.ProviderQuota, 0, 3)
+	for _, p := range s.providers() {
+		quotas = append(quotas, p.quota.state(p.name))
 	}
+	return quotas
+}
 
-	var result struct {
-		Country []struct {
-			CountryID   string  `json:"country_id"`
-			Probability float64 `json:"probability"`
-		} `json:"country"`
+// OfflineDataset reports the loaded offline dataset, or false when the
+// offline provider is disabled.
+func (s *EnrichmentService) OfflineDataset() (models.OfflineDataset, bool) {
+	if s.dataset == nil {
+		return models.OfflineDataset{}, false
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	return s.dataset.Status(), true
+}
+
+// ReloadOffline re-reads the offline dataset file. It is a no-op when the
+// offline provider is disabled.
+func (s *EnrichmentService) ReloadOffline() error {
+	if s.dataset == nil {
+		return nil
 	}
+	return s.dataset.Reload()
+}
 
-	if len(result.Country) == 0 {
-		return "", fmt.Errorf("no nationality prediction available")
+// begin registers an enrichment with inflight unless the service is
+// draining.
+func (s *EnrichmentService) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
 	}
+	s.inflight.Add(1)
+	return true
+}
 
-	nationality := result.Country[0].CountryID
-	logrus.WithFields(logrus.Fields{
-		"name":        name,
-		"nationality": nationality,
-	}).Debug("Nationality successfully retrieved")
-	return nationality, nil
+// Drain rejects new enrichment with ErrDraining and blocks until every
+// in-flight enrichment has finished or ctx expires.
+func (s *EnrichmentService) Drain(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/names"
+	"github.com/sirupsen/logrus"
+)
+
+// maxBatchNames is the most names agify, genderize and nationalize accept
+// in a single request.
+const maxBatchNames = 10
+
+// Predictions follow the agify, genderize and nationalize response bodies.
+// Probability and Count are nil when a source does not report them.
+type agePrediction struct {
+	Name  string `json:"name"`
+	Age   *int   `json:"age"`
+	Count *int   `json:"count"`
+}
+
+type genderPrediction struct {
+	Name        string   `json:"name"`
+	Gender      *string  `json:"gender"`
+	Probability *float64 `json:"probability"`
+	Count       *int     `json:"count"`
+}
+
+type nationalityPrediction struct {
+	Name    string              `json:"name"`
+	Count   *int                `json:"count"`
+	Country []countryPrediction `json:"country"`
+}
+
+type countryPrediction struct {
+	CountryID   string   `json:"country_id"`
+	Probability *float64 `json:"probability"`
+}
+
+// batchLookup queries p for names in chunks of maxBatchNames and returns
+// the predictions by name. Names whose chunk failed are listed in errs.
+func batchLookup[T any](ctx context.Context, p *provider, names []string, countryID string) (map[string]T, map[string]error) {
+	predictions := make(map[string]T, len(names))
+	errs := make(map[string]error)
+
+	for start := 0; start < len(names); start += maxBatchNames {
+		chunk := names[start:min(start+maxBatchNames, len(names))]
+		logrus.WithFields(logrus.Fields{
+			"provider": p.name,
+			"names":    len(chunk),
+		}).Debug("Fetching predictions")
+
+		results, err := fetchPredictions[T](ctx, p, chunk, countryID)
+		if err != nil {
+			for _, name := range chunk {
+				errs[name] = err
+			}
+			continue
+		}
+		for i, name := range chunk {
+			predictions[name] = results[i]
+		}
+	}
+	return predictions, errs
+}
+
+// fetchPredictions returns one prediction per name, in request order. A
+// single name yields a JSON object, several names a JSON array.
+func fetchPredictions[T any](ctx context.Context, p *provider, names []string, countryID string) ([]T, error) {
+	resp, err := p.get(ctx, names, countryID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []T
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			return nil, err
+		}
+	} else {
+		var result T
+		if err := json.Unmarshal(trimmed, &result); err != nil {
+			return nil, err
+		}
+		results = []T{result}
+	}
+
+	if len(results) != len(names) {
+		return nil, fmt.Errorf("%s returned %d predictions for %d names", p.name, len(results), len(names))
+	}
+	return results, nil
+}
+
+// lookupName is the spelling of the first name sent to the providers.
+func (s *EnrichmentService) lookupName(p *models.Person) string {
+	if s.latinNames {
+		return names.Transliterate(p.Name)
+	}
+	return p.Name
+}
+
+func (s *EnrichmentService) distinctNames(reqs []EnrichRequest) []string {
+	seen := make(map[string]bool, len(reqs))
+	var distinct []string
+	for _, r := range reqs {
+		name := s.lookupName(r.Person)
+		if !seen[name] {
+			seen[name] = true
+			distinct = append(distinct, name)
+		}
+	}
+	return distinct
+}
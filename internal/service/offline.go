@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// offlineSourceName is recorded as the provider of values from the dataset.
+const offlineSourceName = "offline"
+
+// datasetEntry is one row of an offline dataset. Every attribute is
+// optional, so a dataset may cover only some of them.
+type datasetEntry struct {
+	Name                   string   `json:"name"`
+	Age                    *int     `json:"age"`
+	Gender                 *string  `json:"gender"`
+	GenderProbability      *float64 `json:"gender_probability"`
+	Nationality            *string  `json:"nationality"`
+	NationalityProbability *float64 `json:"nationality_probability"`
+	Count                  *int     `json:"count"`
+}
+
+// Dataset is an in-memory index of an offline name dataset. Reload swaps
+// the index atomically, so lookups never see a half-loaded file.
+type Dataset struct {
+	path string
+
+	mu       sync.RWMutex
+	entries  map[string]datasetEntry
+	loadedAt time.Time
+}
+
+// LoadDataset reads a CSV or JSON dataset, chosen by the file extension.
+func LoadDataset(path string) (*Dataset, error) {
+	d := &Dataset{path: path}
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Reload reads the file again. On error the previous index stays in use.
+func (d *Dataset) Reload() error {
+	entries, err := readDataset(d.path)
+	if err != nil {
+		return fmt.Errorf("load offline dataset %s: %w", d.path, err)
+	}
+
+	index := make(map[string]datasetEntry, len(entries))
+	for _, e := range entries {
+		index[datasetKey(e.Name)] = e
+	}
+
+	d.mu.Lock()
+	d.entries = index
+	d.loadedAt = time.Now()
+	d.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"file":    d.path,
+		"entries": len(index),
+	}).Info("Offline dataset loaded")
+	return nil
+}
+
+// Status reports the file, size and load time of the index.
+func (d *Dataset) Status() models.OfflineDataset {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return models.OfflineDataset{
+		File:     d.path,
+		Entries:  len(d.entries),
+		LoadedAt: d.loadedAt,
+	}
+}
+
+func (d *Dataset) get(name string) (datasetEntry, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	e, ok := d.entries[datasetKey(name)]
+	return e, ok
+}
+
+func datasetKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func readDataset(path string) ([]datasetEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []datasetEntry
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&entries); err != nil {
+			return nil, err
+		}
+	case ".csv":
+		entries, err = readCSVDataset(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q, want .csv or .json", ext)
+	}
+
+	for i, e := range entries {
+		if err := e.validate(); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+	return entries, nil
+}
+
+// readCSVDataset reads a CSV file whose header names the columns, using the
+// JSON field names of datasetEntry. Empty cells are missing values.
+func readCSVDataset(r io.Reader) ([]datasetEntry, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, col := range header {
+		col = strings.TrimSpace(col)
+		switch col {
+		case "name", "age", "gender", "gender_probability", "nationality", "nationality_probability", "count":
+			columns[col] = i
+		default:
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New(`missing column "name"`)
+	}
+
+	var entries []datasetEntry
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		row := csvRow{record: record, columns: columns}
+		e := datasetEntry{
+			Name:                   row.get("name"),
+			Age:                    parseCell(&row, "age", strconv.Atoi),
+			Gender:                 parseCell(&row, "gender", parseString),
+			GenderProbability:      parseCell(&row, "gender_probability", parseFloat),
+			Nationality:            parseCell(&row, "nationality", parseString),
+			NationalityProbability: parseCell(&row, "nationality_probability", parseFloat),
+			Count:                  parseCell(&row, "count", strconv.Atoi),
+		}
+		if row.err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, row.err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+type csvRow struct {
+	record  []string
+	columns map[string]int
+	err     error
+}
+
+func (r *csvRow) get(col string) string {
+	i, ok := r.columns[col]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+// parseCell returns nil for a missing column or an empty cell and records
+// the first parse error in the row.
+func parseCell[T any](r *csvRow, col string, parse func(string) (T, error)) *T {
+	cell := r.get(col)
+	if cell == "" {
+		return nil
+	}
+	v, err := parse(cell)
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("column %s: invalid value %q", col, cell)
+		}
+		return nil
+	}
+	return &v
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func (e datasetEntry) validate() error {
+	if strings.TrimSpace(e.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	if e.Age != nil && *e.Age <= 0 {
+		return fmt.Errorf("age must be positive, got %d", *e.Age)
+	}
+	if e.Gender != nil && *e.Gender != "male" && *e.Gender != "female" && *e.Gender != "other" {
+		return fmt.Errorf("gender must be male, female or other, got %q", *e.Gender)
+	}
+	if e.Nationality != nil && len(*e.Nationality) != 2 {
+		return fmt.Errorf("nationality must be an ISO 3166-1 alpha-2 code, got %q", *e.Nationality)
+	}
+	for _, p := range []*float64{e.GenderProbability, e.NationalityProbability} {
+		if p != nil && (*p < 0 || *p > 1) {
+			return fmt.Errorf("probability must be between 0 and 1, got %g", *p)
+		}
+	}
+	if e.Count != nil && *e.Count < 0 {
+		return fmt.Errorf("count must not be negative, got %d", *e.Count)
+	}
+	return nil
+}
+
+// offlineSource answers lookups from a Dataset. It ignores country hints.
+type offlineSource[T any] struct {
+	dataset *Dataset
+	predict func(datasetEntry) T
+}
+
+func (s offlineSource[T]) name() string { return offlineSourceName }
+
+func (s offlineSource[T]) lookup(_ context.Context, names []string, _ string) (map[string]T, map[string]error) {
+	predictions := make(map[string]T, len(names))
+	for _, name := range names {
+		if e, ok := s.dataset.get(name); ok {
+			predictions[name] = s.predict(e)
+		}
+	}
+	return predictions, nil
+}
+
+func offlineAge(e datasetEntry) agePrediction {
+	return agePrediction{Name: e.Name, Age: e.Age, Count: e.Count}
+}
+
+func offlineGender(e datasetEntry) genderPrediction {
+	return genderPrediction{Name: e.Name, Gender: e.Gender, Probability: e.GenderProbability, Count: e.Count}
+}
+
+func offlineNationality(e datasetEntry) nationalityPrediction {
+	p := nationalityPrediction{Name: e.Name, Count: e.Count}
+	if e.Nationality != nil {
+		p.Country = []countryPrediction{{
+			CountryID:   strings.ToUpper(*e.Nationality),
+			Probability: e.NationalityProbability,
+		}}
+	}
+	return p
+}
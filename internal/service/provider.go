@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// provider is one enrichment API together with its quota, retry and circuit
+// breaker state.
+type provider struct {
+	name    string
+	baseURL string
+	apiKey  string
+	client  *httpClient
+	quota   *quota
+	retry   retryPolicy
+	breaker *breaker
+}
+
+func newProvider(name, baseURL string, client *httpClient, cfg config.EnrichmentConfig) *provider {
+	return &provider{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  cfg.APIKey,
+		client:  client,
+		quota:   newQuota(cfg.QuotaReserve, cfg.QuotaResetInterval),
+		retry: retryPolicy{
+			attempts:   cfg.RetryAttempts,
+			backoff:    cfg.RetryBackoff,
+			maxBackoff: cfg.RetryMaxBackoff,
+		},
+		breaker: newBreaker(name, cfg.BreakerThreshold, cfg.BreakerOpenTimeout),
+	}
+}
+
+// get queries the provider for names, narrowed to countryID when it is not
+// empty. The caller must close the body of the returned response, which
+// always has status 200.
+func (p *provider) get(ctx context.Context, names []string, countryID string) (*http.Response, error) {
+	if err := p.breaker.allow(); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.name, err)
+	}
+
+	metrics.Add(p.name+"_requests_total", 1)
+	resp, err := p.retry.do(ctx, p.name, func() (*http.Response, error) {
+		// Every attempt spends quota, so every attempt asks for it.
+		if err := p.quota.acquire(); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		resp, err := p.client.get(ctx, p.queryURL(names, countryID))
+		if err == nil {
+			p.quota.record(resp)
+		}
+		return resp, err
+	})
+	switch {
+	case errors.Is(err, ErrQuotaExhausted), err != nil && ctx.Err() != nil:
+		p.breaker.release()
+		return nil, err
+	case err != nil:
+		metrics.Add(p.name+"_failures_total", 1)
+		p.breaker.record(false)
+		return nil, err
+	case resp.StatusCode >= http.StatusInternalServerError:
+		metrics.Add(p.name+"_failures_total", 1)
+		p.breaker.record(false)
+	default:
+		p.breaker.record(true)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		logrus.WithField("provider", p.name).Warn("Provider quota exhausted")
+		return nil, fmt.Errorf("%s: %w", p.name, ErrQuotaExhausted)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("API returned status: %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// queryURL escapes names, so names with spaces, '&' or Cyrillic letters
+// reach the API intact. A single name is sent as name, several as name[].
+// The key of a paid plan is sent as apikey.
+func (p *provider) queryURL(names []string, countryID string) string {
+	params := url.Values{}
+	if len(names) == 1 {
+		params.Set("name", names[0])
+	} else {
+		params["name[]"] = names
+	}
+	if countryID != "" {
+		params.Set("country_id", countryID)
+	}
+	if p.apiKey != "" {
+		params.Set("apikey", p.apiKey)
+	}
+	return p.baseURL + "/?" + params.Encode()
+}
+
+// check reports transport failures and 5xx responses. Any other status means
+// the provider is reachable.
+func (p *provider) check(ctx context.Context) error {
+	resp, err := p.client.get(ctx, p.baseURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("API returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+)
+
+var ErrQuotaExhausted = errors.New("provider quota exhausted")
+
+// quota tracks a provider's request allowance as reported by the
+// X-Rate-Limit-* response headers of agify, genderize and nationalize.
+type quota struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	known     bool
+	resetAt   time.Time
+	updatedAt time.Time
+	reserve   int
+	// window is how long a low allowance is trusted when the provider did
+	// not say when it resets.
+	window time.Duration
+	now    func() time.Time
+}
+
+func newQuota(reserve int, window time.Duration) *quota {
+	return &quota{reserve: reserve, window: window, now: time.Now}
+}
+
+// acquire refuses a request when the last known remaining allowance is at or
+// below the reserve and the window has not reset yet.
+func (q *quota) acquire() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.known || q.remaining > q.reserve {
+		if q.known {
+			// Count the request now so concurrent callers do not all slip
+			// through on the same remaining value.
+			q.remaining--
+		}
+		return nil
+	}
+	if !q.now().Before(q.reset()) {
+		q.known = false
+		q.resetAt = time.Time{}
+		return nil
+	}
+	return ErrQuotaExhausted
+}
+
+// reset is when the current window ends: as reported by the provider or,
+// without a report, window after the allowance was last seen.
+func (q *quota) reset() time.Time {
+	if !q.resetAt.IsZero() {
+		return q.resetAt
+	}
+	return q.updatedAt.Add(q.window)
+}
+
+func (q *quota) record(resp *http.Response) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	if limit, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Limit")); err == nil {
+		q.limit = limit
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Remaining")); err == nil {
+		q.remaining = remaining
+		q.known = true
+		q.updatedAt = now
+		// A reset time from an earlier response belongs to an earlier window.
+		q.resetAt = time.Time{}
+	}
+	if reset, err := strconv.Atoi(resp.Header.Get("X-Rate-Limit-Reset")); err == nil {
+		q.resetAt = now.Add(time.Duration(reset) * time.Second)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		q.remaining = 0
+		q.known = true
+		q.updatedAt = now
+		q.resetAt = time.Time{}
+		if retry, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			q.resetAt = now.Add(time.Duration(retry) * time.Second)
+		}
+	}
+}
+
+func (q *quota) state(provider string) models.ProviderQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	state := models.ProviderQuota{Provider: provider, Reserve: q.reserve}
+	if q.limit > 0 {
+		limit := q.limit
+		state.Limit = &limit
+	}
+	if q.known {
+		remaining := q.remaining
+		updatedAt := q.updatedAt
+		state.Remaining = &remaining
+		state.UpdatedAt = &updatedAt
+		state.Throttled = remaining <= q.reserve && q.now().Before(q.reset())
+		resetAt := q.reset()
+		state.ResetAt = &resetAt
+	}
+	return state
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// retryPolicy retries idempotent requests that failed in transit or with a
+// 5xx status, sleeping a random ("full jitter") share of an exponentially
+// growing backoff between attempts.
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func (rp retryPolicy) do(ctx context.Context, provider string, fn func() (*http.Response, error)) (*http.Response, error) {
+	backoff := rp.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := fn()
+		if !retryable(ctx, resp, err) || attempt >= rp.attempts {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := time.Duration(rand.Int64N(int64(backoff) + 1))
+		logrus.WithFields(logrus.Fields{
+			"provider": provider,
+			"attempt":  attempt,
+			"delay":    delay.String(),
+			"error":    err,
+		}).Debug("Retrying enrichment request")
+		metrics.Add(provider+"_retries_total", 1)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff = min(backoff*2, rp.maxBackoff)
+	}
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if errors.Is(err, ErrQuotaExhausted) {
+		return false
+	}
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
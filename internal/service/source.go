@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// source answers lookups of one attribute for many names at once. Names it
+// cannot answer are left out of the result, with the cause in errs when
+// there is one.
+type source[T any] interface {
+	name() string
+	lookup(ctx context.Context, names []string, countryID string) (map[string]T, map[string]error)
+}
+
+// apiSource looks names up through one of the HTTP APIs.
+type apiSource[T any] struct {
+	p *provider
+}
+
+func (s apiSource[T]) name() string { return s.p.name }
+
+func (s apiSource[T]) lookup(ctx context.Context, names []string, countryID string) (map[string]T, map[string]error) {
+	return batchLookup[T](ctx, s.p, names, countryID)
+}
+
+// answer is a prediction together with the source that produced it.
+type answer[T any] struct {
+	prediction T
+	source     string
+}
+
+// chain asks its links in order, each one only for the names the previous
+// ones could not answer. usable tells a real prediction from an empty one,
+// such as agify's null age for an unknown name. API predictions are stored
+// in the cache, if the chain has one.
+type chain[T any] struct {
+	links  []link[T]
+	cache  *cacheSource[T]
+	usable func(T) bool
+}
+
+// link is a source with its own time budget; zero means no limit beyond
+// the caller's context.
+type link[T any] struct {
+	source  source[T]
+	timeout time.Duration
+	// cacheable links have their predictions stored in the chain's cache.
+	cacheable bool
+}
+
+func (c chain[T]) lookup(ctx context.Context, names []string, countryID string) (map[string]answer[T], map[string]error) {
+	answers := make(map[string]answer[T], len(names))
+	errs := make(map[string]error)
+
+	pending := names
+	for _, l := range c.links {
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+		predictions, srcErrs := l.lookup(ctx, pending, countryID)
+
+		var missing []string
+		for _, name := range pending {
+			if p, ok := predictions[name]; ok && c.usable(p) {
+				answers[name] = answer[T]{prediction: p, source: l.source.name()}
+				delete(errs, name)
+				if c.cache != nil && l.cacheable {
+					c.cache.store(name, countryID, p)
+				}
+				continue
+			}
+			if err, ok := srcErrs[name]; ok {
+				errs[name] = err
+			} else if _, ok := errs[name]; !ok {
+				errs[name] = fmt.Errorf("%s has no prediction", l.source.name())
+			}
+			missing = append(missing, name)
+		}
+		pending = missing
+	}
+	for _, name := range pending {
+		if _, ok := errs[name]; !ok {
+			errs[name] = ctx.Err()
+		}
+	}
+	return answers, errs
+}
+
+func (l link[T]) lookup(ctx context.Context, names []string, countryID string) (map[string]T, map[string]error) {
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	return l.source.lookup(ctx, names, countryID)
+}
+
+// names lists the sources in the order they are asked.
+func (c chain[T]) names() string {
+	names := make([]string, len(c.links))
+	for i, l := range c.links {
+		names[i] = l.source.name()
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,31 @@
+// Package webhooks stores webhook subscriptions and delivers person events
+// to them.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderSignature  = "X-Webhook-Signature"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderEventID    = "X-Event-ID"
+	HeaderEventType  = "X-Event-Type"
+	HeaderDeliveryID = "X-Webhook-Delivery-ID"
+)
+
+// Sign returns the signature header value for body sent at timestamp:
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with
+// secret. Including the timestamp lets receivers reject replays.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
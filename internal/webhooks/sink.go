@@ -0,0 +1,38 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+)
+
+// Sink is the outbox sink that queues a delivery of each event for every
+// active subscription to its type. Queuing is idempotent, so an event the
+// relay publishes twice is still delivered once per subscription.
+type Sink struct {
+	db *sql.DB
+}
+
+func NewSink(db *sql.DB) *Sink {
+	return &Sink{db: db}
+}
+
+func (s *Sink) Name() string { return "subscriptions" }
+
+func (s *Sink) Publish(ctx context.Context, event models.PersonEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3 FROM webhook_subscriptions
+		WHERE active AND $2 = ANY (event_types)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		event.ID, event.Type, payload)
+	return err
+}
+
+func (s *Sink) Close() error { return nil }
@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/models"
+	"github.com/lib/pq"
+)
+
+const subscriptionColumns = "id, url, event_types, active, consecutive_failures, disabled_reason, created_at, updated_at"
+
+const deliveryColumns = "id, subscription_id, event_id, event_type, status, attempts, last_status_code, last_error, next_attempt_at, created_at, delivered_at"
+
+// Store keeps subscriptions and their delivery log in Postgres.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create stores a new subscription. An empty secret is replaced with a
+// random one, which the returned subscription carries.
+func (s *Store) Create(ctx context.Context, req models.WebhookSubscriptionRequest) (models.WebhookSubscription, error) {
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	}
+	active := req.Active == nil || *req.Active
+
+	var sub models.WebhookSubscription
+	err := scanSubscription(s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, event_types, secret, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+subscriptionColumns,
+		req.URL, pq.Array(req.EventTypes), secret, active), &sub)
+	sub.Secret = secret
+	return sub, err
+}
+
+// Update replaces a subscription. The secret is kept when req has none;
+// setting Active resets the failure count. It returns sql.ErrNoRows for an
+// unknown id.
+func (s *Store) Update(ctx context.Context, id int, req models.WebhookSubscriptionRequest) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := scanSubscription(s.db.QueryRowContext(ctx, `
+		UPDATE webhook_subscriptions SET
+			url = $1,
+			event_types = $2,
+			secret = COALESCE(NULLIF($3, ''), secret),
+			active = COALESCE($4::boolean, active),
+			consecutive_failures = CASE WHEN $4::boolean IS NULL THEN consecutive_failures ELSE 0 END,
+			disabled_reason = CASE WHEN $4::boolean IS NULL THEN disabled_reason ELSE NULL END,
+			updated_at = now()
+		WHERE id = $5
+		RETURNING `+subscriptionColumns,
+		req.URL, pq.Array(req.EventTypes), req.Secret, req.Active, id), &sub)
+	return sub, err
+}
+
+// Get returns sql.ErrNoRows for an unknown id.
+func (s *Store) Get(ctx context.Context, id int) (models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	err := scanSubscription(s.db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE id = $1", id), &sub)
+	return sub, err
+}
+
+func (s *Store) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []models.WebhookSubscription{}
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// Delete removes a subscription and its delivery log. It returns
+// sql.ErrNoRows for an unknown id.
+func (s *Store) Delete(ctx context.Context, id int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Deliveries lists the deliveries of a subscription, newest first,
+// optionally only those with status.
+func (s *Store) Deliveries(ctx context.Context, subscriptionID int, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`, subscriptionID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		if d.Status != models.DeliveryPending {
+			d.NextAttemptAt = nil
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner, sub *models.WebhookSubscription) error {
+	return row.Scan(&sub.ID, &sub.URL, pq.Array(&sub.EventTypes), &sub.Active, &sub.ConsecutiveFailures,
+		&sub.DisabledReason, &sub.CreatedAt, &sub.UpdatedAt)
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,255 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Krchnk/EffectiveMobileFullNameTest/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+var metrics = expvar.NewMap("webhooks")
+
+const (
+	pruneInterval = time.Hour
+	// maxErrorLength bounds the response excerpt kept in the delivery log.
+	maxErrorLength = 512
+)
+
+// Worker sends queued deliveries. Each batch is claimed by pushing its
+// next attempt past the request timeout, so replicas running their own
+// worker skip it while it is in flight.
+type Worker struct {
+	db     *sql.DB
+	client *http.Client
+	cfg    config.WebhooksConfig
+
+	lastPrune time.Time
+}
+
+func NewWorker(db *sql.DB, cfg config.WebhooksConfig) *Worker {
+	return &Worker{db: db, client: &http.Client{Timeout: cfg.Timeout}, cfg: cfg}
+}
+
+type delivery struct {
+	id             int64
+	subscriptionID int
+	eventID        int64
+	eventType      string
+	payload        []byte
+	attempts       int
+	url            string
+	secret         string
+}
+
+// Run sends deliveries until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	logrus.Info("Webhook delivery worker started")
+	for {
+		n, err := w.deliverBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warn("Failed to send webhook deliveries")
+		}
+		if n == w.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+		w.prune(ctx)
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Webhook delivery worker stopped")
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+func (w *Worker) deliverBatch(ctx context.Context) (int, error) {
+	batch, err := w.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for _, d := range batch {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(d delivery) {
+			defer func() { <-sem; wg.Done() }()
+			code, err := w.send(ctx, d)
+			if ctx.Err() != nil {
+				// The claim expires and the delivery is sent again.
+				return
+			}
+			if err := w.record(ctx, d, code, err); err != nil {
+				logrus.WithError(err).WithField("delivery_id", d.id).Error("Failed to record webhook delivery")
+			}
+		}(d)
+	}
+	wg.Wait()
+	return len(batch), nil
+}
+
+func (w *Worker) claim(ctx context.Context) ([]delivery, error) {
+	rows, err := w.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + $2 * interval '1 second'
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT d2.id FROM webhook_deliveries d2
+			JOIN webhook_subscriptions s2 ON s2.id = d2.subscription_id
+			WHERE d2.status = 'pending' AND d2.next_attempt_at <= now() AND s2.active
+			ORDER BY d2.id
+			LIMIT $1
+			FOR UPDATE OF d2 SKIP LOCKED)
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret`,
+		w.cfg.BatchSize, (2 * w.cfg.Timeout).Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []delivery
+	for rows.Next() {
+		var d delivery
+		if err := rows.Scan(&d.id, &d.subscriptionID, &d.eventID, &d.eventType, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			return nil, err
+		}
+		batch = append(batch, d)
+	}
+	return batch, rows.Err()
+}
+
+// send POSTs the signed payload and returns the response status. Any
+// status outside 2xx is an error.
+func (w *Worker) send(ctx context.Context, d delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(d.secret, now, d.payload))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderEventID, strconv.FormatInt(d.eventID, 10))
+	req.Header.Set(HeaderEventType, d.eventType)
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(d.id, 10))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of an attempt. A failure schedules the next
+// attempt or gives the delivery up, and counts against the subscription.
+func (w *Worker) record(ctx context.Context, d delivery, code int, sendErr error) error {
+	var status *int
+	if code != 0 {
+		status = &code
+	}
+	attempts := d.attempts + 1
+	log := logrus.WithFields(logrus.Fields{
+		"delivery_id":     d.id,
+		"subscription_id": d.subscriptionID,
+		"event_id":        d.eventID,
+		"attempt":         attempts,
+	})
+
+	if sendErr == nil {
+		metrics.Add("delivered_total", 1)
+		log.Debug("Webhook delivered")
+		if _, err := w.db.ExecContext(ctx, `
+			UPDATE webhook_deliveries
+			SET status = 'succeeded', attempts = $2, last_status_code = $3, last_error = NULL, delivered_at = now()
+			WHERE id = $1`, d.id, attempts, status); err != nil {
+			return err
+		}
+		_, err := w.db.ExecContext(ctx, "UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0", d.subscriptionID)
+		return err
+	}
+
+	metrics.Add("failed_attempts_total", 1)
+	errText := sendErr.Error()
+	if len(errText) > maxErrorLength {
+		errText = errText[:maxErrorLength]
+	}
+	newStatus := "pending"
+	if attempts >= w.cfg.MaxAttempts {
+		newStatus = "failed"
+		metrics.Add("given_up_total", 1)
+		log.WithError(sendErr).Warn("Giving up webhook delivery")
+	} else {
+		log.WithError(sendErr).Debug("Webhook delivery failed, will retry")
+	}
+	if _, err := w.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = $5,
+		    next_attempt_at = now() + $6 * interval '1 second'
+		WHERE id = $1`, d.id, newStatus, attempts, status, errText, w.backoff(attempts).Seconds()); err != nil {
+		return err
+	}
+
+	var disabled bool
+	err := w.db.QueryRowContext(ctx, `
+		UPDATE webhook_subscriptions SET
+			consecutive_failures = consecutive_failures + 1,
+			active = active AND consecutive_failures + 1 < $2,
+			disabled_reason = CASE WHEN active AND consecutive_failures + 1 >= $2
+				THEN $3 ELSE disabled_reason END,
+			updated_at = now()
+		WHERE id = $1
+		RETURNING NOT active AND consecutive_failures = $2`,
+		d.subscriptionID, w.cfg.DisableAfter,
+		fmt.Sprintf("disabled after %d consecutive failed deliveries, last error: %s", w.cfg.DisableAfter, errText)).Scan(&disabled)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		metrics.Add("disabled_total", 1)
+		logrus.WithFields(logrus.Fields{
+			"subscription_id": d.subscriptionID,
+			"url":             d.url,
+		}).Warn("Webhook subscription disabled after repeated failures")
+	}
+	return nil
+}
+
+// backoff is the delay before the attempt after the given one.
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.cfg.RetryBackoff
+	for i := 1; i < attempts && d < w.cfg.RetryMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.cfg.RetryMaxBackoff)
+}
+
+// prune deletes finished deliveries older than the retention, at most
+// once per pruneInterval.
+func (w *Worker) prune(ctx context.Context) {
+	if time.Since(w.lastPrune) < pruneInterval {
+		return
+	}
+	w.lastPrune = time.Now()
+
+	_, err := w.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < now() - $1 * interval '1 second'",
+		w.cfg.Retention.Seconds())
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to delete old webhook deliveries")
+	}
+}
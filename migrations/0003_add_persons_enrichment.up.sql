@@ -0,0 +1 @@
+ALTER TABLE persons ADD COLUMN enrichment JSONB;
@@ -0,0 +1,4 @@
+ALTER TABLE persons
+    DROP COLUMN name_latin,
+    DROP COLUMN surname_latin,
+    DROP COLUMN patronymic_latin;
@@ -0,0 +1,7 @@
+ALTER TABLE persons
+    ADD COLUMN name_latin VARCHAR(255),
+    ADD COLUMN surname_latin VARCHAR(255),
+    ADD COLUMN patronymic_latin VARCHAR(255);
+
+CREATE INDEX idx_persons_name_latin ON persons (name_latin);
+CREATE INDEX idx_persons_surname_latin ON persons (surname_latin);